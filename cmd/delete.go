@@ -11,18 +11,15 @@ func init() {
 }
 
 var deleteCmd = &cobra.Command{
-	Use:     "delete [project]",
-	Short:   "Delete a tmux session/project",
+	Use:     "delete [project...]",
+	Short:   "Delete tmux sessions/projects",
 	Aliases: []string{"d"},
-	Args:    cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var projectName string
-		if len(args) == 0 {
-			projectName = ""
-		} else {
-			projectName = args[0]
+		if len(args) == 1 {
+			return AppService.DeleteProject(project.Name(args[0]))
 		}
 
-		return AppService.DeleteProject(project.Name(projectName))
+		// no args launches multi-select
+		return AppService.DeleteProjects(toProjectNames(args))
 	},
 }
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(entriesCmd)
+}
+
+// used by the selector to reload its list, not meant to be run by hand
+var entriesCmd = &cobra.Command{
+	Use:    "__entries",
+	Short:  "Print selector entries",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return AppService.WriteSelectorEntries(os.Stdout)
+	},
+}
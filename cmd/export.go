@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"os"
+	"thop/internal/output"
+	"thop/internal/types/project"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat string
+	exportAll    bool
+)
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", string(output.FormatShell), "export format: sh or yaml (bundle of templates)")
+	exportCmd.Flags().BoolVarP(&exportAll, "all", "a", false, "export all templates as a yaml bundle")
+	rootCmd.AddCommand(exportCmd)
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export [project...]",
+	Short: "Export a tmux session/project, so it can be recreated without thop or moved to another machine",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := output.Format(exportFormat)
+		if exportAll && !cmd.Flags().Changed("format") {
+			format = output.FormatYAML
+		}
+
+		switch format {
+		case output.FormatYAML:
+			b, err := AppService.ExportProjects(toProjectNames(args), exportAll)
+			if err != nil {
+				return err
+			}
+
+			return output.WriteBundle(os.Stdout, b)
+		case output.FormatShell:
+			if exportAll || len(args) > 1 {
+				return output.ErrUnsupportedFormat.WithMsg("sh format exports a single project, use yaml format for more")
+			}
+
+			var projectName string
+			if len(args) == 0 {
+				projectName = ""
+			} else {
+				projectName = args[0]
+			}
+
+			plan, err := AppService.ExportScript(project.Name(projectName))
+			if err != nil {
+				return err
+			}
+
+			return output.WriteScript(os.Stdout, plan)
+		default:
+			return output.ErrUnknownFormat.WithMsg("unknown format ", exportFormat, ", expected one of: sh, yaml")
+		}
+	},
+}
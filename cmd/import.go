@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"thop/internal/importer"
+	"thop/internal/service"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFrom     string
+	importMappings []string
+	importConflict string
+	importKeepUUID bool
+)
+
+func init() {
+	importCmd.Flags().StringVar(&importFrom, "from", "", "format of imported files: tmuxinator or tmuxp, thop bundle if not set")
+	importCmd.Flags().StringArrayVar(&importMappings, "map", nil, "rewrite roots starting with old path to new path, in old=new form (repeatable)")
+	importCmd.Flags().StringVar(&importConflict, "conflict", "", "what to do when project with the same name exists: skip, overwrite or rename")
+	importCmd.Flags().BoolVar(&importKeepUUID, "keep-uuid", false, "keep project UUIDs from the bundle")
+	rootCmd.AddCommand(importCmd)
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import <file|dir>",
+	Short: "Import thop bundles, tmuxinator or tmuxp configs as session templates",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var roots []importer.RootMapping
+		for _, m := range importMappings {
+			mapping, err := importer.ParseRootMapping(m)
+			if err != nil {
+				return err
+			}
+			roots = append(roots, mapping)
+		}
+
+		return AppService.ImportProjects(args[0], service.ImportOptions{
+			From:     importer.Source(importFrom),
+			Roots:    roots,
+			Conflict: service.ConflictStrategy(importConflict),
+			KeepUUID: importKeepUUID,
+		})
+	},
+}
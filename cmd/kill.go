@@ -1,28 +1,53 @@
 package cmd
 
 import (
+	"thop/internal/service"
 	"thop/internal/types/project"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
+var killRemoveWorktree bool
+var killAll bool
+var killOthers bool
+var killIdle time.Duration
+var killRegex bool
+var killYes bool
+var killSwitchTo string
+
 func init() {
+	killCmd.Flags().BoolVar(&killRemoveWorktree, "remove-worktree", false, "remove git worktree the session was opened in, unless it has uncommitted changes")
+	killCmd.Flags().BoolVarP(&killAll, "all", "a", false, "kill all running sessions")
+	killCmd.Flags().BoolVar(&killOthers, "others", false, "kill all sessions except the current one")
+	killCmd.Flags().DurationVar(&killIdle, "idle", 0, "kill sessions without activity for at least the duration, e.g. 2h")
+	killCmd.Flags().BoolVar(&killRegex, "regex", false, "match session names with regular expressions instead of globs")
+	killCmd.Flags().BoolVarP(&killYes, "yes", "y", false, "don't ask for confirmation when killing sessions in bulk")
+	killCmd.Flags().StringVar(&killSwitchTo, "switch-to", "", "project or session to switch to when killing the session you're in")
+	killCmd.MarkFlagsMutuallyExclusive("all", "others")
 	rootCmd.AddCommand(killCmd)
 }
 
 var killCmd = &cobra.Command{
-	Use:     "kill [session]",
-	Short:   "Kill active tmux session",
+	Use:     "kill [session|pattern...]",
+	Short:   "Kill active tmux sessions",
 	Aliases: []string{"k"},
-	Args:    cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var projectName string
-		if len(args) == 0 {
-			projectName = ""
-		} else {
-			projectName = args[0]
+		opts := service.KillOptions{
+			RemoveWorktree: killRemoveWorktree,
+			All:            killAll,
+			Others:         killOthers,
+			Idle:           killIdle,
+			Regex:          killRegex,
+			Yes:            killYes,
+			SwitchTo:       project.Name(killSwitchTo),
+		}
+
+		if len(args) == 1 {
+			return AppService.KillSession(project.Name(args[0]), opts)
 		}
 
-		return AppService.KillSession(project.Name(projectName))
+		// no args launches multi-select
+		return AppService.KillSessions(toProjectNames(args), opts)
 	},
 }
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(lastCmd)
+}
+
+var lastCmd = &cobra.Command{
+	Use:     "last",
+	Short:   "Switch to the previously used tmux session/project, same as `thop -`",
+	Aliases: []string{"l"},
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return AppService.OpenLast()
+	},
+}
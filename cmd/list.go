@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"os"
+	"thop/internal/output"
+	"thop/internal/service"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	listFilter service.ListFilter
+	listFormat string
+)
+
+func init() {
+	listCmd.Flags().BoolVar(&listFilter.Templates, "templates", false, "list projects with a template")
+	listCmd.Flags().BoolVar(&listFilter.Sessions, "sessions", false, "list sessions without a template")
+	listCmd.Flags().BoolVar(&listFilter.Running, "running", false, "list only projects with a running session")
+	listCmd.Flags().StringVarP(&listFormat, "format", "f", string(output.FormatTable), "output format: table, tsv, json or go-template=<template>")
+	rootCmd.AddCommand(listCmd)
+}
+
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List tmux sessions/projects in a non-interactive way",
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		items, err := AppService.ListProjects(listFilter)
+		if err != nil {
+			return err
+		}
+
+		return output.WriteList(os.Stdout, output.Format(listFormat), items)
+	},
+}
@@ -1,12 +1,27 @@
 package cmd
 
 import (
+	"thop/internal/service"
 	"thop/internal/types/project"
 
 	"github.com/spf13/cobra"
 )
 
+var openAll bool
+var openSave bool
+var openWorktree string
+var openPickWorktree bool
+var openPath string
+var openRebuild bool
+
 func init() {
+	openCmd.Flags().BoolVarP(&openAll, "all", "a", false, "open multiple projects at once, attaching to the first one")
+	openCmd.Flags().StringVarP(&openPath, "path", "p", "", "open a session for the directory without saving a template")
+	openCmd.Flags().BoolVar(&openSave, "save", false, "save the opened directory as a project")
+	openCmd.Flags().StringVarP(&openWorktree, "worktree", "w", "", "open the project in git worktree of the branch, creating it when needed")
+	openCmd.Flags().BoolVar(&openPickWorktree, "worktrees", false, "select from existing git worktrees of the project")
+	openCmd.Flags().BoolVar(&openRebuild, "rebuild", false, "recreate the running session from the current template")
+	openCmd.MarkFlagsMutuallyExclusive("all", "worktree", "worktrees", "path", "rebuild")
 	rootCmd.AddCommand(openCmd)
 }
 
@@ -14,8 +29,20 @@ var openCmd = &cobra.Command{
 	Use:     "open [project]",
 	Short:   "Open a tmux session/project",
 	Aliases: []string{"o", "select", "s"},
-	Args:    cobra.MaximumNArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if openAll {
+			return nil
+		}
+		if openPath != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.MaximumNArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if openAll {
+			return AppService.OpenProjects(toProjectNames(args))
+		}
+
 		var projectName string
 		if len(args) == 0 {
 			projectName = ""
@@ -23,6 +50,12 @@ var openCmd = &cobra.Command{
 			projectName = args[0]
 		}
 
-		return AppService.OpenProject(project.Name(projectName))
+		return AppService.OpenProject(project.Name(projectName), service.OpenOptions{
+			Path:         openPath,
+			Save:         openSave,
+			Worktree:     openWorktree,
+			PickWorktree: openPickWorktree,
+			Rebuild:      openRebuild,
+		})
 	},
 }
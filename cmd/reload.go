@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"thop/internal/service"
+	"thop/internal/types/project"
+
+	"github.com/spf13/cobra"
+)
+
+var reloadIncremental bool
+
+func init() {
+	reloadCmd.Flags().BoolVarP(&reloadIncremental, "incremental", "i", false, "only add windows and panes missing in the session, keeping existing ones untouched")
+	rootCmd.AddCommand(reloadCmd)
+}
+
+var reloadCmd = &cobra.Command{
+	Use:     "reload [project]",
+	Short:   "Apply template changes to the running session",
+	Aliases: []string{"r"},
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var projectName string
+		if len(args) == 1 {
+			projectName = args[0]
+		}
+
+		return AppService.ReloadProject(project.Name(projectName), service.ReloadOptions{Incremental: reloadIncremental})
+	},
+}
@@ -6,24 +6,59 @@ import (
 	"thop/internal/problem"
 	"thop/internal/selector"
 	"thop/internal/service"
+	"thop/internal/types/project"
 
 	"github.com/spf13/cobra"
 )
 
 var AppService service.Service
 
+// Options are global flags affecting how AppService is built
+type Options struct {
+	DryRun bool
+	// Socket of the tmux server to use, overrides the environment and the config file
+	Socket string
+}
+
+// NewAppService builds AppService once global flags are parsed, it needs to be set before Execute
+var NewAppService func(Options) service.Service
+
+var options Options
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&options.DryRun, "dry-run", false, "print tmux commands and file changes instead of running them")
+	rootCmd.PersistentFlags().StringVar(&options.Socket, "socket", "", "name or path of the socket of the tmux server to use")
+}
+
 var rootCmd = &cobra.Command{
 	Use:           "thop",
 	Short:         "Thop is a quick & lightweight tmux session/project manager",
 	SilenceErrors: true,
 	SilenceUsage:  true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		AppService = NewAppService(options)
+	},
+	Args: func(cmd *cobra.Command, args []string) error {
+		// "-" and "." are not valid command names, so they are handled by root itself
+		if len(args) == 1 && (args[0] == "-" || args[0] == ".") {
+			return nil
+		}
+		return cobra.NoArgs(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(args) == 0 {
 			// No args, defaults to open command
 			return openCmd.RunE(cmd, args)
 		}
 
-		return nil
+		// "thop ." opens current directory without saving a template
+		if args[0] == "." {
+			openPath = "."
+			return openCmd.RunE(cmd, nil)
+		}
+
+		// "thop -" jumps back to the previous session, just like "cd -"
+		return lastCmd.RunE(cmd, nil)
 	},
 }
 
@@ -48,3 +83,11 @@ func Execute() {
 		}
 	}
 }
+
+func toProjectNames(args []string) []project.Name {
+	var names []project.Name
+	for _, arg := range args {
+		names = append(names, project.Name(arg))
+	}
+	return names
+}
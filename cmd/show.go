@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"os"
+	"thop/internal/output"
+	"thop/internal/types/project"
+
+	"github.com/spf13/cobra"
+)
+
+var showFormat string
+
+func init() {
+	showCmd.Flags().StringVarP(&showFormat, "format", "f", string(output.FormatYAML), "output format: yaml or json")
+	rootCmd.AddCommand(showCmd)
+}
+
+var showCmd = &cobra.Command{
+	Use:   "show [project]",
+	Short: "Show resolved template of a project and tmux commands used to build it",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var projectName string
+		if len(args) == 0 {
+			projectName = ""
+		} else {
+			projectName = args[0]
+		}
+
+		plan, err := AppService.ShowProject(project.Name(projectName))
+		if err != nil {
+			return err
+		}
+
+		return output.WritePlan(os.Stdout, output.Format(showFormat), plan)
+	},
+}
@@ -0,0 +1,69 @@
+package bundle
+
+import (
+	"thop/internal/problem"
+	"thop/internal/types"
+	"thop/internal/types/project"
+
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	ErrInvalidBundle     problem.Key = "BUNDLE_INVALID"
+	ErrUnsupportedBundle problem.Key = "BUNDLE_UNSUPPORTED_VERSION"
+)
+
+const (
+	Version = types.V1
+)
+
+// Bundle carries templates between machines, unlike template files it keeps UUIDs of projects
+type Bundle struct {
+	Version  types.Version `yaml:"version"`
+	Projects []Entry       `yaml:"projects"`
+}
+
+type Entry struct {
+	UUID            project.UUID `yaml:"uuid"`
+	project.Project `yaml:",inline"`
+}
+
+func New(projects []project.Project) Bundle {
+	b := Bundle{Version: Version, Projects: []Entry{}}
+	for _, p := range projects {
+		b.Projects = append(b.Projects, Entry{UUID: p.UUID, Project: p})
+	}
+	return b
+}
+
+func Parse(data []byte) (Bundle, error) {
+	var b Bundle
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return Bundle{}, ErrInvalidBundle.WithMsg(err.Error())
+	}
+
+	if b.Version != Version {
+		return Bundle{}, ErrUnsupportedBundle.WithMsg("unsupported bundle version ", b.Version, ", expected ", Version)
+	}
+
+	return b, nil
+}
+
+func (b Bundle) Marshal() ([]byte, error) {
+	bytes, err := yaml.Marshal(b)
+	if err != nil {
+		return nil, ErrInvalidBundle.WithMsg(err.Error())
+	}
+	return bytes, nil
+}
+
+// List returns projects of the bundle with UUIDs restored
+func (b Bundle) List() []project.Project {
+	var projects []project.Project
+	for _, e := range b.Projects {
+		p := e.Project
+		p.UUID = e.UUID
+		projects = append(projects, p)
+	}
+	return projects
+}
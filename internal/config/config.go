@@ -1,11 +1,222 @@
 package config
 
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"thop/internal/fsystem"
+	"thop/internal/problem"
+
+	"github.com/goccy/go-yaml"
+)
+
 type Config struct {
-	ConfigDir  string
-	Editor     string
-	InsideTmux bool
+	ConfigDir  string `yaml:"-"`
+	Editor     string `yaml:"-"`
+	InsideTmux bool   `yaml:"-"`
+
+	// below fields are read from the config file
+	Selector SelectorConfig `yaml:"selector"`
+	// Sources are read-only template directories, listed in order of precedence
+	Sources   []SourceConfig  `yaml:"sources"`
+	Discovery DiscoveryConfig `yaml:"discovery"`
+	Kill      KillConfig      `yaml:"kill"`
+	Tmux      TmuxConfig      `yaml:"tmux"`
+}
+
+type Order string
+
+const (
+	OrderAlphabetical Order = "alphabetical"
+	OrderFrecency     Order = "frecency"
+)
+
+type SelectorConfig struct {
+	Order Order `yaml:"order"`
+}
+
+// KillFallback decides what happens to the client when the session it's in gets killed
+type KillFallback string
+
+const (
+	FallbackSwitch KillFallback = "switch" // switch to the previous (or any other) session
+	FallbackDetach KillFallback = "detach"
+)
+
+type KillConfig struct {
+	Fallback KillFallback `yaml:"fallback"`
+}
+
+type TmuxConfig struct {
+	// Socket is name (tmux -L) or path (tmux -S) of the socket of the server sessions are run on
+	Socket string `yaml:"socket"`
 }
 
+type SourceConfig struct {
+	Name string `yaml:"name"`
+	Path string `yaml:"path"`
+}
+
+// DiscoveryConfig lists directories as candidates for ad-hoc sessions, next to templates
+type DiscoveryConfig struct {
+	Paths []SearchPath `yaml:"paths"`
+	// Ignore globs are matched against directory names, hidden directories and node_modules by default
+	Ignore []string `yaml:"ignore"`
+	// GitOnly lists only git repositories, repositories are never descended into either way
+	GitOnly bool `yaml:"git_only"`
+	// Zoxide adds directories known to zoxide
+	Zoxide bool `yaml:"zoxide"`
+}
+
+type SearchPath struct {
+	Path string `yaml:"path"`
+	// Depth is how deep below the path directories are listed, 1 (only direct children) by default
+	Depth int `yaml:"depth"`
+}
+
+var defaultIgnore = []string{".*", "node_modules"}
+
+const (
+	ErrFailedToReadConfig problem.Key = "CONFIG_FAILED_TO_READ"
+	ErrInvalidConfig      problem.Key = "CONFIG_INVALID"
+)
+
+const configFileName = "config.yaml"
+
+// SocketEnv overrides tmux socket set in the config file
+const SocketEnv = "THOP_TMUX_SOCKET"
+
 func (c *Config) GetConfigDir() string { return c.ConfigDir }
 func (c *Config) GetEditor() string    { return c.Editor }
 func (c *Config) IsInsideTmux() bool   { return c.InsideTmux }
+
+// Load reads the config file from the config dir on top of defaults, missing file is not an error
+func (c *Config) Load(fsys fsystem.FileSystem) error {
+	c.Selector.Order = OrderAlphabetical
+	c.Discovery.Ignore = defaultIgnore
+	c.Kill.Fallback = FallbackSwitch
+
+	bytes, err := fsys.ReadFile(filepath.Join(c.ConfigDir, configFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return c.resolveSocket()
+	}
+	if err != nil {
+		return ErrFailedToReadConfig.WithMsg(err.Error())
+	}
+
+	if err := yaml.Unmarshal(bytes, c); err != nil {
+		return ErrInvalidConfig.WithMsg(err.Error())
+	}
+
+	switch c.Selector.Order {
+	case "":
+		c.Selector.Order = OrderAlphabetical
+	case OrderAlphabetical, OrderFrecency:
+	default:
+		return ErrInvalidConfig.WithMsg("unknown selector order ", c.Selector.Order)
+	}
+
+	switch c.Kill.Fallback {
+	case "":
+		c.Kill.Fallback = FallbackSwitch
+	case FallbackSwitch, FallbackDetach:
+	default:
+		return ErrInvalidConfig.WithMsg("unknown kill fallback ", c.Kill.Fallback)
+	}
+
+	if err := c.validateSources(); err != nil {
+		return err
+	}
+
+	if err := c.validateDiscovery(); err != nil {
+		return err
+	}
+
+	return c.resolveSocket()
+}
+
+func (c *Config) resolveSocket() error {
+	if socket := os.Getenv(SocketEnv); socket != "" {
+		c.Tmux.Socket = socket
+	}
+
+	socket, err := expandHome(c.Tmux.Socket)
+	if err != nil {
+		return err
+	}
+	c.Tmux.Socket = socket
+
+	return nil
+}
+
+func (c *Config) validateSources() error {
+	var names []string
+	for i, source := range c.Sources {
+		if source.Name == "" || source.Path == "" {
+			return ErrInvalidConfig.WithMsg("template source ", i+1, " needs both name and path")
+		}
+
+		if slices.Contains(names, source.Name) {
+			return ErrInvalidConfig.WithMsg("duplicate template source name ", source.Name)
+		}
+		names = append(names, source.Name)
+
+		path, err := expandHome(source.Path)
+		if err != nil {
+			return err
+		}
+		c.Sources[i].Path = path
+	}
+
+	return nil
+}
+
+func (c *Config) validateDiscovery() error {
+	for i, searchPath := range c.Discovery.Paths {
+		if searchPath.Path == "" {
+			return ErrInvalidConfig.WithMsg("discovery path ", i+1, " needs a path")
+		}
+
+		switch {
+		case searchPath.Depth < 0:
+			return ErrInvalidConfig.WithMsg("discovery path ", searchPath.Path, " has negative depth")
+		case searchPath.Depth == 0:
+			c.Discovery.Paths[i].Depth = 1
+		}
+
+		path, err := expandHome(searchPath.Path)
+		if err != nil {
+			return err
+		}
+		c.Discovery.Paths[i].Path = path
+	}
+
+	if c.Discovery.Ignore == nil {
+		c.Discovery.Ignore = defaultIgnore
+	}
+
+	for _, pattern := range c.Discovery.Ignore {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return ErrInvalidConfig.WithMsg("invalid discovery ignore pattern ", pattern)
+		}
+	}
+
+	return nil
+}
+
+// expands leading ~, paths in config are often shared between machines through dotfiles
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", ErrInvalidConfig.WithMsg(err.Error())
+	}
+
+	return filepath.Join(home, path[1:]), nil
+}
@@ -0,0 +1,134 @@
+package discovery
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"thop/internal/config"
+	"thop/internal/executor"
+	"thop/internal/fsystem"
+	"thop/internal/problem"
+)
+
+type Discoverer interface {
+	// Discover returns directories to offer for ad-hoc sessions, in order they were found
+	Discover() ([]string, error)
+}
+
+// DirectoryDiscoverer scans configured search paths and optionally zoxide database,
+// unreadable paths are reported and skipped, so one missing mount doesn't hide the rest
+type DirectoryDiscoverer struct {
+	Config     config.DiscoveryConfig
+	FileSystem fsystem.FileSystem
+	E          executor.CommandExecutor
+	// Warnings receives reports of skipped paths, stderr when not set
+	Warnings io.Writer
+}
+
+const (
+	ErrFailedToQueryZoxide problem.Key = "DISCOVERY_FAILED_TO_QUERY_ZOXIDE"
+)
+
+const gitDirName = ".git"
+
+func (d *DirectoryDiscoverer) Discover() ([]string, error) {
+	var dirs []string
+
+	for _, searchPath := range d.Config.Paths {
+		entries, err := d.FileSystem.ReadDir(searchPath.Path)
+		if err != nil {
+			d.warn("Failed to scan", searchPath.Path+":", err.Error())
+			continue
+		}
+
+		dirs = d.walk(dirs, searchPath.Path, entries, searchPath.Depth)
+	}
+
+	if d.Config.Zoxide {
+		known, err := d.queryZoxide()
+		if err != nil {
+			d.warn(err.Error())
+		}
+
+		for _, dir := range known {
+			if !slices.Contains(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	return dirs, nil
+}
+
+// walks children of the dir, git repositories are treated as leaves since they are projects on their own
+func (d *DirectoryDiscoverer) walk(dirs []string, dir string, entries []os.DirEntry, depth int) []string {
+	for _, entry := range entries {
+		if !entry.IsDir() || d.ignored(entry.Name()) {
+			continue
+		}
+
+		child := filepath.Join(dir, entry.Name())
+		childEntries, err := d.FileSystem.ReadDir(child)
+		if err != nil {
+			// most likely permissions, not worth reporting for every nested directory
+			continue
+		}
+
+		if isGitRepository(childEntries) {
+			dirs = append(dirs, child)
+			continue
+		}
+
+		if !d.Config.GitOnly {
+			dirs = append(dirs, child)
+		}
+
+		if depth > 1 {
+			dirs = d.walk(dirs, child, childEntries, depth-1)
+		}
+	}
+
+	return dirs
+}
+
+func (d *DirectoryDiscoverer) ignored(name string) bool {
+	return slices.ContainsFunc(d.Config.Ignore, func(pattern string) bool {
+		matched, _ := filepath.Match(pattern, name)
+		return matched
+	})
+}
+
+func (d *DirectoryDiscoverer) queryZoxide() ([]string, error) {
+	cmd := exec.Command("zoxide", "query", "--list")
+
+	output, _, err := d.E.Execute(cmd)
+	if err != nil {
+		return nil, ErrFailedToQueryZoxide.WithMsg("failed to query zoxide: ", err.Error())
+	}
+
+	var dirs []string
+	for line := range strings.SplitSeq(output, "\n") {
+		if line != "" {
+			dirs = append(dirs, line)
+		}
+	}
+
+	return dirs, nil
+}
+
+func (d *DirectoryDiscoverer) warn(a ...any) {
+	w := d.Warnings
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintln(w, a...)
+}
+
+// worktrees and submodules have .git file instead of a directory, so don't check the type
+func isGitRepository(entries []os.DirEntry) bool {
+	return slices.ContainsFunc(entries, func(entry os.DirEntry) bool { return entry.Name() == gitDirName })
+}
@@ -1,8 +1,15 @@
 package executor
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
 )
 
 type CommandExecutor interface {
@@ -25,3 +32,118 @@ func (s *ShellExecutor) ExecuteInteractive(cmd *exec.Cmd) (int, error) {
 	err := cmd.Run()
 	return cmd.ProcessState.ExitCode(), err
 }
+
+// Stderr returns what the failed command wrote to stderr, empty when it wasn't captured
+func Stderr(err error) string {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(exitErr.Stderr)
+	}
+	return ""
+}
+
+// RecordingExecutor records commands instead of executing them, every command succeeds with no output
+type RecordingExecutor struct {
+	Commands [][]string
+}
+
+func (r *RecordingExecutor) Execute(cmd *exec.Cmd) (string, int, error) {
+	r.Commands = append(r.Commands, cmd.Args)
+	return "", 0, nil
+}
+
+func (r *RecordingExecutor) ExecuteInteractive(cmd *exec.Cmd) (int, error) {
+	r.Commands = append(r.Commands, cmd.Args)
+	return 0, nil
+}
+
+// CommandLine joins args into a line that can be pasted into a POSIX shell
+func CommandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_@%+=:,./-", r)
+	}) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// DryRunExecutor prints commands instead of running them, commands which only read state
+// are still executed, so the rest of the flow behaves the same as in a real run
+type DryRunExecutor struct {
+	Inner CommandExecutor
+	Out   io.Writer
+}
+
+func (d *DryRunExecutor) Execute(cmd *exec.Cmd) (string, int, error) {
+	if isReadOnly(cmd.Args) {
+		return d.Inner.Execute(cmd)
+	}
+
+	fmt.Fprintln(d.Out, "[dry-run]", CommandLine(cmd.Args))
+	return "", 0, nil
+}
+
+func (d *DryRunExecutor) ExecuteInteractive(cmd *exec.Cmd) (int, error) {
+	if isReadOnly(cmd.Args) {
+		return d.Inner.ExecuteInteractive(cmd)
+	}
+
+	fmt.Fprintln(d.Out, "[dry-run]", CommandLine(cmd.Args))
+	return 0, nil
+}
+
+// programs (and their subcommands) safe to run in dry-run mode, nil means any subcommand,
+// nested subcommands are separated by space
+var readOnlyCommands = map[string][]string{
+	"fzf":    nil,
+	"git":    {"worktree list", "for-each-ref", "status"},
+	"tmux":   {"has-session", "list-sessions", "list-windows", "list-panes", "display-message", "show-options"},
+	"zoxide": {"query"},
+}
+
+// global flags followed by a value, given before the subcommand
+var valueFlags = map[string][]string{
+	"git":  {"-C", "-c"},
+	"tmux": {"-L", "-S", "-f"},
+}
+
+func isReadOnly(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	program := filepath.Base(args[0])
+	subcommands, ok := readOnlyCommands[program]
+	if !ok {
+		return false
+	}
+
+	if subcommands == nil {
+		return true
+	}
+
+	// subcommands are the leading non-flag arguments
+	var words []string
+	for i := 1; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			words = append(words, args[i])
+			continue
+		}
+		if len(words) == 0 && slices.Contains(valueFlags[program], args[i]) {
+			i++ // value of the flag, e.g. socket in tmux -L work
+		}
+	}
+
+	return slices.ContainsFunc(subcommands, func(subcommand string) bool {
+		fields := strings.Fields(subcommand)
+		return len(words) >= len(fields) && slices.Equal(words[:len(fields)], fields)
+	})
+}
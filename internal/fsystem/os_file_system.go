@@ -1,6 +1,12 @@
 package fsystem
 
-import "os"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
 
 type FileSystem interface {
 	MkdirAll(path string) error
@@ -35,3 +41,37 @@ func (s *OsFileSystem) WriteFile(path string, data []byte) error {
 func (s *OsFileSystem) RemoveAll(path string) error {
 	return os.RemoveAll(path)
 }
+
+// DryRunFileSystem prints changes instead of making them, reads go to the inner file system
+type DryRunFileSystem struct {
+	Inner FileSystem
+	Out   io.Writer
+}
+
+// MkdirAll is skipped silently, directories are created by thop as a side effect of most commands
+func (s *DryRunFileSystem) MkdirAll(path string) error {
+	return nil
+}
+
+// ReadDir treats missing directories as empty, since in a real run they would have been created by now
+func (s *DryRunFileSystem) ReadDir(path string) ([]os.DirEntry, error) {
+	entries, err := s.Inner.ReadDir(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return entries, err
+}
+
+func (s *DryRunFileSystem) ReadFile(path string) ([]byte, error) {
+	return s.Inner.ReadFile(path)
+}
+
+func (s *DryRunFileSystem) WriteFile(path string, data []byte) error {
+	fmt.Fprintln(s.Out, "[dry-run] write", path)
+	return nil
+}
+
+func (s *DryRunFileSystem) RemoveAll(path string) error {
+	fmt.Fprintln(s.Out, "[dry-run] remove", path)
+	return nil
+}
@@ -0,0 +1,126 @@
+package git
+
+import (
+	"os/exec"
+	"strings"
+	"thop/internal/executor"
+	"thop/internal/problem"
+)
+
+type GitClient interface {
+	// ListWorktrees lists worktrees of the repository the dir belongs to, the main one comes first
+	ListWorktrees(dir string) ([]Worktree, error)
+	// BranchExists checks local branches, and remote ones which git checks out as tracking branches
+	BranchExists(repo string, branch string) (bool, error)
+	// AddWorktree checks out the branch at path, creating the branch first when asked to
+	AddWorktree(repo string, path string, branch string, create bool) error
+	// IsClean reports whether the worktree has no uncommitted or untracked changes
+	IsClean(path string) (bool, error)
+	RemoveWorktree(repo string, path string) error
+}
+
+type GitClientImpl struct {
+	E executor.CommandExecutor
+}
+
+type Worktree struct {
+	Path string
+	// Branch is empty for detached worktrees
+	Branch string
+	Bare   bool
+}
+
+const (
+	ErrFailedToListWorktrees  problem.Key = "GIT_FAILED_TO_LIST_WORKTREES"
+	ErrFailedToCheckBranch    problem.Key = "GIT_FAILED_TO_CHECK_BRANCH"
+	ErrFailedToAddWorktree    problem.Key = "GIT_FAILED_TO_ADD_WORKTREE"
+	ErrFailedToCheckStatus    problem.Key = "GIT_FAILED_TO_CHECK_STATUS"
+	ErrFailedToRemoveWorktree problem.Key = "GIT_FAILED_TO_REMOVE_WORKTREE"
+)
+
+func (c *GitClientImpl) ListWorktrees(dir string) ([]Worktree, error) {
+	cmd := exec.Command("git", "worktree", "list", "--porcelain")
+	cmd.Dir = dir
+
+	output, _, err := c.E.Execute(cmd)
+	if err != nil {
+		return nil, ErrFailedToListWorktrees.WithMsg("failed to list worktrees of ", dir, " (is it a git repository?): ", err.Error())
+	}
+
+	return parseWorktrees(output), nil
+}
+
+// porcelain format lists attributes line by line, worktrees are separated by an empty line
+func parseWorktrees(output string) []Worktree {
+	var worktrees []Worktree
+
+	for line := range strings.SplitSeq(output, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+
+		switch key {
+		case "worktree":
+			worktrees = append(worktrees, Worktree{Path: value})
+		case "branch":
+			if len(worktrees) > 0 {
+				worktrees[len(worktrees)-1].Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		case "bare":
+			if len(worktrees) > 0 {
+				worktrees[len(worktrees)-1].Bare = true
+			}
+		}
+	}
+
+	return worktrees
+}
+
+func (c *GitClientImpl) BranchExists(repo string, branch string) (bool, error) {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname)", "refs/heads/"+branch, "refs/remotes/*/"+branch)
+	cmd.Dir = repo
+
+	output, _, err := c.E.Execute(cmd)
+	if err != nil {
+		return false, ErrFailedToCheckBranch.WithMsg(err.Error())
+	}
+
+	return strings.TrimSpace(output) != "", nil
+}
+
+func (c *GitClientImpl) AddWorktree(repo string, path string, branch string, create bool) error {
+	cmd := exec.Command("git", "worktree", "add")
+	if create {
+		cmd.Args = append(cmd.Args, "-b", branch, path)
+	} else {
+		cmd.Args = append(cmd.Args, path, branch)
+	}
+	cmd.Dir = repo
+
+	if _, _, err := c.E.Execute(cmd); err != nil {
+		return ErrFailedToAddWorktree.WithMsg(err.Error())
+	}
+
+	return nil
+}
+
+func (c *GitClientImpl) IsClean(path string) (bool, error) {
+	cmd := exec.Command("git", "status", "--porcelain")
+	cmd.Dir = path
+
+	output, _, err := c.E.Execute(cmd)
+	if err != nil {
+		return false, ErrFailedToCheckStatus.WithMsg(err.Error())
+	}
+
+	return strings.TrimSpace(output) == "", nil
+}
+
+func (c *GitClientImpl) RemoveWorktree(repo string, path string) error {
+	cmd := exec.Command("git", "worktree", "remove", path)
+	cmd.Dir = repo
+
+	if _, _, err := c.E.Execute(cmd); err != nil {
+		return ErrFailedToRemoveWorktree.WithMsg(err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package history
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"thop/internal/config"
+	"thop/internal/fsystem"
+	"thop/internal/problem"
+	"thop/internal/types/project"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+type History interface {
+	Record(project.Project) error
+	Entries() ([]Entry, error)
+}
+
+// Entry tracks usage of a single project, templates are identified by UUID
+// while sessions without a template are identified by their name
+type Entry struct {
+	UUID     project.UUID `yaml:"uuid,omitempty"`
+	Name     project.Name `yaml:"name"`
+	Count    int          `yaml:"count"`
+	LastUsed time.Time    `yaml:"last_used"`
+}
+
+type YamlHistory struct {
+	Config     *config.Config
+	FileSystem fsystem.FileSystem
+	Now        func() time.Time
+}
+
+const (
+	ErrFailedToReadHistory  problem.Key = "HISTORY_FAILED_TO_READ"
+	ErrFailedToWriteHistory problem.Key = "HISTORY_FAILED_TO_WRITE"
+)
+
+const historyFileName = "history.yaml"
+
+type historyFile struct {
+	Entries []Entry `yaml:"entries"`
+}
+
+// Matches reports whether the entry was recorded for given project
+func (e Entry) Matches(p project.Project) bool {
+	if p.UUID != "" || e.UUID != "" {
+		return e.UUID == p.UUID
+	}
+	return e.Name == p.Name
+}
+
+// Frecency scores the entry by how often and how recently it was used,
+// weights are borrowed from z/zoxide which seem to work well in practice
+func (e Entry) Frecency(now time.Time) float64 {
+	age := now.Sub(e.LastUsed)
+
+	switch {
+	case age < time.Hour:
+		return float64(e.Count) * 4
+	case age < 24*time.Hour:
+		return float64(e.Count) * 2
+	case age < 7*24*time.Hour:
+		return float64(e.Count) * 0.5
+	default:
+		return float64(e.Count) * 0.25
+	}
+}
+
+func (h *YamlHistory) Record(p project.Project) error {
+	entries, err := h.Entries()
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(entries, func(e Entry) bool { return e.Matches(p) })
+	if i == -1 {
+		entries = append(entries, Entry{UUID: p.UUID})
+		i = len(entries) - 1
+	}
+
+	entries[i].Name = p.Name
+	entries[i].Count++
+	entries[i].LastUsed = h.now()
+
+	bytes, err := yaml.Marshal(historyFile{Entries: entries})
+	if err != nil {
+		return ErrFailedToWriteHistory.WithMsg(err.Error())
+	}
+
+	if err := h.FileSystem.MkdirAll(h.Config.GetConfigDir()); err != nil {
+		return ErrFailedToWriteHistory.WithMsg(err.Error())
+	}
+
+	if err := h.FileSystem.WriteFile(h.path(), bytes); err != nil {
+		return ErrFailedToWriteHistory.WithMsg(err.Error())
+	}
+
+	return nil
+}
+
+// Entries returns all recorded entries, most recently used first
+func (h *YamlHistory) Entries() ([]Entry, error) {
+	bytes, err := h.FileSystem.ReadFile(h.path())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, ErrFailedToReadHistory.WithMsg(err.Error())
+	}
+
+	var file historyFile
+	if err := yaml.Unmarshal(bytes, &file); err != nil {
+		return nil, ErrFailedToReadHistory.WithMsg(err.Error())
+	}
+
+	slices.SortStableFunc(file.Entries, func(a, b Entry) int {
+		return b.LastUsed.Compare(a.LastUsed)
+	})
+
+	return file.Entries, nil
+}
+
+func (h *YamlHistory) path() string {
+	return filepath.Join(h.Config.GetConfigDir(), historyFileName)
+}
+
+func (h *YamlHistory) now() time.Time {
+	if h.Now == nil {
+		return time.Now()
+	}
+	return h.Now()
+}
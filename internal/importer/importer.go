@@ -0,0 +1,169 @@
+package importer
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"thop/internal/problem"
+	"thop/internal/types"
+	"thop/internal/types/command"
+	"thop/internal/types/project"
+
+	"github.com/goccy/go-yaml"
+)
+
+type Source string
+
+const (
+	SourceTmuxinator Source = "tmuxinator"
+	SourceTmuxp      Source = "tmuxp"
+)
+
+const (
+	ErrUnknownSource problem.Key = "IMPORT_UNKNOWN_SOURCE"
+	ErrInvalidConfig problem.Key = "IMPORT_INVALID_CONFIG"
+)
+
+// defaultRoot is used when config doesn't specify one, other tools fall back to working directory instead
+const defaultRoot = "~"
+
+// Result is a converted project, together with keys that have no equivalent in thop and were skipped
+type Result struct {
+	Project     project.Project
+	Unsupported []string
+}
+
+// Convert translates config of another session manager into a project,
+// fallback name is used when config doesn't name the session itself
+func Convert(source Source, fallbackName project.Name, data []byte) (Result, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return Result{}, ErrInvalidConfig.WithMsg(err.Error())
+	}
+
+	c := converter{}
+
+	var p project.Project
+	var err error
+	switch source {
+	case SourceTmuxinator:
+		p, err = c.tmuxinator(raw)
+	case SourceTmuxp:
+		p, err = c.tmuxp(raw)
+	default:
+		return Result{}, ErrUnknownSource.WithMsg("unknown source ", source, ", expected one of: tmuxinator, tmuxp")
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	if p.Name == "" {
+		p.Name = fallbackName
+	}
+	if p.Template.Root == "" {
+		p.Template.Root = defaultRoot
+	}
+	p.Version = types.V1
+
+	return Result{Project: p, Unsupported: c.unsupported}, nil
+}
+
+// converter collects unsupported keys while walking the config
+type converter struct {
+	unsupported []string
+}
+
+// reports every key that wasn't handled, sorted so the report is stable
+func (c *converter) reportUnknown(path string, m map[string]any, known ...string) {
+	var keys []string
+	for key := range m {
+		if !slices.Contains(known, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+	for _, key := range keys {
+		c.unsupported = append(c.unsupported, joinPath(path, key))
+	}
+}
+
+// commands accepts a single command, a list of commands or (for tmuxp) a list of {cmd: ...} maps
+func (c *converter) commands(path string, v any) ([]command.Command, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		var commands []command.Command
+		for i, item := range v {
+			if m, ok := item.(map[string]any); ok {
+				c.reportUnknown(joinPath(path, fmt.Sprint(i)), m, "cmd")
+				item = m["cmd"]
+			}
+
+			s, err := scalar(joinPath(path, fmt.Sprint(i)), item)
+			if err != nil {
+				return nil, err
+			}
+			if s != "" {
+				commands = append(commands, command.Command(s))
+			}
+		}
+		return commands, nil
+	default:
+		s, err := scalar(path, v)
+		if err != nil || s == "" {
+			return nil, err
+		}
+		return []command.Command{command.Command(s)}, nil
+	}
+}
+
+func scalar(path string, v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case map[string]any, []any:
+		return "", ErrInvalidConfig.WithMsg(path, " is expected to be a single value")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+func mapping(path string, v any) (map[string]any, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return v, nil
+	default:
+		return nil, ErrInvalidConfig.WithMsg(path, " is expected to be a mapping")
+	}
+}
+
+func list(path string, v any) ([]any, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return v, nil
+	default:
+		return nil, ErrInvalidConfig.WithMsg(path, " is expected to be a list")
+	}
+}
+
+// relative roots are relative to the parent root in both tmuxinator and tmuxp
+func joinRoot(parent string, root string) string {
+	if root == "" || parent == "" || filepath.IsAbs(root) || strings.HasPrefix(root, "~") || strings.HasPrefix(root, "$") {
+		return root
+	}
+	return filepath.Join(parent, root)
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"slices"
+	"strings"
+	"thop/internal/problem"
+	"thop/internal/types/pane"
+	"thop/internal/types/project"
+	"thop/internal/types/template"
+	"thop/internal/types/window"
+)
+
+const (
+	ErrInvalidRootMapping problem.Key = "IMPORT_INVALID_ROOT_MAPPING"
+)
+
+// RootMapping rewrites roots starting with From, so templates can be moved between machines
+type RootMapping struct {
+	From string
+	To   string
+}
+
+// ParseRootMapping parses mapping in the old=new form
+func ParseRootMapping(s string) (RootMapping, error) {
+	from, to, found := strings.Cut(s, "=")
+	if !found || from == "" || to == "" {
+		return RootMapping{}, ErrInvalidRootMapping.WithMsg("invalid root mapping ", s, ", expected old=new")
+	}
+
+	return RootMapping{From: strings.TrimSuffix(from, "/"), To: strings.TrimSuffix(to, "/")}, nil
+}
+
+// rewrites the path when it's From itself or lies under it, so /home/al doesn't match /home/alice
+func (m RootMapping) rewrite(path string) (string, bool) {
+	if path == m.From {
+		return m.To, true
+	}
+
+	if rest, found := strings.CutPrefix(path, m.From+"/"); found {
+		return m.To + "/" + rest, true
+	}
+
+	return path, false
+}
+
+// MapRoots rewrites template, window and pane roots, first matching mapping wins
+func MapRoots(p project.Project, mappings []RootMapping) project.Project {
+	if len(mappings) == 0 {
+		return p
+	}
+
+	mapRoot := func(path string) string {
+		for _, m := range mappings {
+			if rewritten, ok := m.rewrite(path); ok {
+				return rewritten
+			}
+		}
+		return path
+	}
+
+	p.Template.Root = template.Root(mapRoot(string(p.Template.Root)))
+
+	p.Template.Windows = slices.Clone(p.Template.Windows)
+	for i, w := range p.Template.Windows {
+		p.Template.Windows[i].Root = window.Root(mapRoot(string(w.Root)))
+
+		p.Template.Windows[i].Panes = slices.Clone(w.Panes)
+		for j, pn := range w.Panes {
+			p.Template.Windows[i].Panes[j].Root = pane.Root(mapRoot(string(pn.Root)))
+		}
+	}
+
+	return p
+}
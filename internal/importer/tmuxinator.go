@@ -0,0 +1,141 @@
+package importer
+
+import (
+	"fmt"
+	"thop/internal/types/pane"
+	"thop/internal/types/project"
+	"thop/internal/types/template"
+	"thop/internal/types/window"
+)
+
+// tmuxinator keeps deprecated aliases around, so accept them as well
+func (c *converter) tmuxinator(raw map[string]any) (project.Project, error) {
+	c.reportUnknown("", raw, "name", "root", "project_root", "pre_window", "pre_tab", "windows", "tabs")
+
+	name, err := scalar("name", raw["name"])
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	root, err := scalar("root", firstSet(raw["root"], raw["project_root"]))
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	preWindow, err := c.commands("pre_window", firstSet(raw["pre_window"], raw["pre_tab"]))
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	rawWindows, err := list("windows", firstSet(raw["windows"], raw["tabs"]))
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	var windows []window.Window
+	for i, rawWindow := range rawWindows {
+		w, err := c.tmuxinatorWindow(fmt.Sprintf("windows.%d", i), root, rawWindow)
+		if err != nil {
+			return project.Project{}, err
+		}
+		windows = append(windows, w)
+	}
+
+	return project.Project{
+		Name: project.Name(name),
+		Template: template.Template{
+			Root:     template.Root(root),
+			Commands: preWindow,
+			Windows:  windows,
+		},
+	}, nil
+}
+
+// every window is a single key mapping of its name to commands, or to a mapping with panes
+func (c *converter) tmuxinatorWindow(path string, root string, v any) (window.Window, error) {
+	m, err := mapping(path, v)
+	if err != nil {
+		return window.Window{}, err
+	}
+	if len(m) != 1 {
+		return window.Window{}, ErrInvalidConfig.WithMsg(path, " is expected to have a single window name as key")
+	}
+
+	var w window.Window
+	for name, value := range m {
+		w.Name = window.Name(name)
+		path = joinPath("windows", name)
+
+		options, isMapping := value.(map[string]any)
+		if !isMapping {
+			w.Commands, err = c.commands(path, value)
+			return w, err
+		}
+
+		c.reportUnknown(path, options, "root", "layout", "pre", "panes")
+
+		windowRoot, err := scalar(joinPath(path, "root"), options["root"])
+		if err != nil {
+			return window.Window{}, err
+		}
+		w.Root = window.Root(joinRoot(root, windowRoot))
+
+		layout, err := scalar(joinPath(path, "layout"), options["layout"])
+		if err != nil {
+			return window.Window{}, err
+		}
+		w.Layout = window.Layout(layout)
+
+		if w.Commands, err = c.commands(joinPath(path, "pre"), options["pre"]); err != nil {
+			return window.Window{}, err
+		}
+
+		rawPanes, err := list(joinPath(path, "panes"), options["panes"])
+		if err != nil {
+			return window.Window{}, err
+		}
+
+		for i, rawPane := range rawPanes {
+			p, err := c.tmuxinatorPane(joinPath(path, fmt.Sprintf("panes.%d", i)), rawPane)
+			if err != nil {
+				return window.Window{}, err
+			}
+			w.Panes = append(w.Panes, p)
+		}
+	}
+
+	return w, nil
+}
+
+// pane is either commands, or a single key mapping of pane name to commands
+func (c *converter) tmuxinatorPane(path string, v any) (pane.Pane, error) {
+	named, isMapping := v.(map[string]any)
+	if !isMapping {
+		commands, err := c.commands(path, v)
+		return pane.Pane{Commands: commands}, err
+	}
+
+	if len(named) != 1 {
+		return pane.Pane{}, ErrInvalidConfig.WithMsg(path, " is expected to have a single pane name as key")
+	}
+
+	var p pane.Pane
+	for name, value := range named {
+		commands, err := c.commands(joinPath(path, name), value)
+		if err != nil {
+			return pane.Pane{}, err
+		}
+		p = pane.Pane{Name: pane.Name(name), Commands: commands}
+	}
+
+	return p, nil
+}
+
+func firstSet(values ...any) any {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package importer
+
+import (
+	"fmt"
+	"thop/internal/types/pane"
+	"thop/internal/types/project"
+	"thop/internal/types/template"
+	"thop/internal/types/window"
+)
+
+func (c *converter) tmuxp(raw map[string]any) (project.Project, error) {
+	c.reportUnknown("", raw, "session_name", "start_directory", "shell_command_before", "environment", "windows")
+
+	name, err := scalar("session_name", raw["session_name"])
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	root, err := scalar("start_directory", raw["start_directory"])
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	before, err := c.commands("shell_command_before", raw["shell_command_before"])
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	rawEnv, err := mapping("environment", raw["environment"])
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	var env template.Env
+	for key, value := range rawEnv {
+		s, err := scalar(joinPath("environment", key), value)
+		if err != nil {
+			return project.Project{}, err
+		}
+		if env == nil {
+			env = template.Env{}
+		}
+		env[key] = s
+	}
+
+	rawWindows, err := list("windows", raw["windows"])
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	var windows []window.Window
+	for i, rawWindow := range rawWindows {
+		w, err := c.tmuxpWindow(i, root, rawWindow)
+		if err != nil {
+			return project.Project{}, err
+		}
+		windows = append(windows, w)
+	}
+
+	return project.Project{
+		Name: project.Name(name),
+		Template: template.Template{
+			Root:     template.Root(root),
+			Commands: before,
+			Windows:  windows,
+			Env:      env,
+		},
+	}, nil
+}
+
+func (c *converter) tmuxpWindow(index int, root string, v any) (window.Window, error) {
+	path := fmt.Sprintf("windows.%d", index)
+	m, err := mapping(path, v)
+	if err != nil {
+		return window.Window{}, err
+	}
+
+	name, err := scalar(joinPath(path, "window_name"), m["window_name"])
+	if err != nil {
+		return window.Window{}, err
+	}
+	if name == "" {
+		// plain index would be taken by tmux as window index when targeting the window
+		name = fmt.Sprintf("window-%d", index+1)
+	} else {
+		path = joinPath("windows", name)
+	}
+
+	c.reportUnknown(path, m, "window_name", "start_directory", "layout", "shell_command_before", "panes")
+
+	windowRoot, err := scalar(joinPath(path, "start_directory"), m["start_directory"])
+	if err != nil {
+		return window.Window{}, err
+	}
+	windowRoot = joinRoot(root, windowRoot)
+
+	layout, err := scalar(joinPath(path, "layout"), m["layout"])
+	if err != nil {
+		return window.Window{}, err
+	}
+
+	before, err := c.commands(joinPath(path, "shell_command_before"), m["shell_command_before"])
+	if err != nil {
+		return window.Window{}, err
+	}
+
+	rawPanes, err := list(joinPath(path, "panes"), m["panes"])
+	if err != nil {
+		return window.Window{}, err
+	}
+
+	// panes are resolved relative to the window root, or session root when window doesn't have one
+	paneParent := windowRoot
+	if paneParent == "" {
+		paneParent = root
+	}
+
+	var panes []pane.Pane
+	for i, rawPane := range rawPanes {
+		p, err := c.tmuxpPane(joinPath(path, fmt.Sprintf("panes.%d", i)), paneParent, rawPane)
+		if err != nil {
+			return window.Window{}, err
+		}
+		panes = append(panes, p)
+	}
+
+	return window.Window{
+		Name:     window.Name(name),
+		Root:     window.Root(windowRoot),
+		Commands: before,
+		Panes:    panes,
+		Layout:   window.Layout(layout),
+	}, nil
+}
+
+// pane is a command, an empty placeholder ("blank", "pane" or null), or a mapping with shell_command
+func (c *converter) tmuxpPane(path string, root string, v any) (pane.Pane, error) {
+	m, isMapping := v.(map[string]any)
+	if !isMapping {
+		if v == "blank" || v == "pane" {
+			return pane.Pane{}, nil
+		}
+		commands, err := c.commands(path, v)
+		return pane.Pane{Commands: commands}, err
+	}
+
+	c.reportUnknown(path, m, "shell_command", "start_directory")
+
+	commands, err := c.commands(joinPath(path, "shell_command"), m["shell_command"])
+	if err != nil {
+		return pane.Pane{}, err
+	}
+
+	paneRoot, err := scalar(joinPath(path, "start_directory"), m["start_directory"])
+	if err != nil {
+		return pane.Pane{}, err
+	}
+
+	return pane.Pane{
+		Root:     pane.Root(joinRoot(root, paneRoot)),
+		Commands: commands,
+	}, nil
+}
@@ -0,0 +1,36 @@
+package multiplexer
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Socket selects the tmux server, either by name (tmux -L) or by path to its socket (tmux -S).
+// Empty socket is the default server.
+type Socket string
+
+func (s Socket) args() []string {
+	switch {
+	case s == "":
+		return nil
+	case strings.Contains(string(s), "/"):
+		return []string{"-S", string(s)}
+	default:
+		return []string{"-L", string(s)}
+	}
+}
+
+// Serves tells whether the socket is the one of the server $TMUX (socket,pid,session) points at,
+// default socket is trusted to serve any client, its location depends on $TMUX_TMPDIR and tmux version
+func (s Socket) Serves(tmuxEnv string) bool {
+	if s == "" || tmuxEnv == "" {
+		return tmuxEnv != ""
+	}
+
+	path, _, _ := strings.Cut(tmuxEnv, ",")
+	if strings.Contains(string(s), "/") {
+		return filepath.Clean(string(s)) == filepath.Clean(path)
+	}
+
+	return filepath.Base(path) == string(s)
+}
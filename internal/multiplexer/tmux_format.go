@@ -0,0 +1,90 @@
+package multiplexer
+
+import (
+	"strconv"
+	"strings"
+	"thop/internal/types/project"
+	"thop/internal/types/window"
+	"time"
+)
+
+// fields are separated with ASCII unit separator, which can't be typed into a session name or path by accident
+const formatDelimiter = "\x1f"
+
+var sessionFields = []string{
+	"#{session_id}",
+	"#{session_name}",
+	"#{session_attached}",
+	"#{session_created}",
+	"#{session_activity}",
+	"#{session_windows}",
+	"#{session_path}",
+	"#{" + ProjectNameOption + "}",
+	"#{" + UUIDOption + "}",
+	"#{" + HashOption + "}",
+}
+
+var sessionFormat = strings.Join(sessionFields, formatDelimiter)
+
+// parses list-sessions output printed with sessionFormat, one session per line
+func parseSessions(output string) ([]Session, error) {
+	var sessions []Session
+
+	for line := range strings.SplitSeq(output, "\n") {
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Split(line, formatDelimiter)
+		if len(fields) != len(sessionFields) {
+			return nil, ErrFailedToListSessions.WithMsg("unexpected tmux output: ", strconv.Quote(line))
+		}
+
+		var numbers [4]int
+		for i, field := range fields[2:6] {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, ErrFailedToListSessions.WithMsg("unexpected tmux output: ", strconv.Quote(line))
+			}
+			numbers[i] = n
+		}
+
+		sessions = append(sessions, Session{
+			ID:          fields[0],
+			Name:        SessionName(fields[1]),
+			Attached:    numbers[0],
+			Created:     time.Unix(int64(numbers[1]), 0).UTC(),
+			Activity:    time.Unix(int64(numbers[2]), 0).UTC(),
+			Windows:     numbers[3],
+			Path:        fields[6],
+			ProjectName: fields[7],
+			UUID:        project.UUID(fields[8]),
+			Hash:        fields[9],
+		})
+	}
+
+	return sessions, nil
+}
+
+var windowFormat = strings.Join([]string{"#{window_name}", "#{window_panes}"}, formatDelimiter)
+
+// parses list-windows output printed with windowFormat, one window per line
+func parseWindows(output string) ([]SessionWindow, error) {
+	var windows []SessionWindow
+
+	for line := range strings.SplitSeq(output, "\n") {
+		if line == "" {
+			continue
+		}
+
+		name, panes, ok := strings.Cut(line, formatDelimiter)
+		count, err := strconv.Atoi(panes)
+		if !ok || err != nil {
+			return nil, ErrFailedToListWindows.WithMsg("unexpected tmux output: ", strconv.Quote(line))
+		}
+
+		windows = append(windows, SessionWindow{Name: window.Name(name), Panes: count})
+	}
+
+	return windows, nil
+}
@@ -2,81 +2,301 @@ package multiplexer
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"thop/internal/config"
+	"thop/internal/executor"
+	"thop/internal/types/pane"
 	"thop/internal/types/project"
+	"thop/internal/types/window"
+	"time"
 )
 
 type Multiplexer interface {
 	AttachProject(project.Project) error
+	StartProject(project.Project) error
+	// RebuildProject recreates the running session from the template and attaches to it
+	RebuildProject(project.Project) error
+	// UpdateProject adds windows and panes missing in the running session and attaches to it
+	UpdateProject(project.Project) error
+	// Plan returns commands that would be run to build the project, without running them
+	Plan(project.Project) ([][]string, error)
 	ListActiveSessions() ([]project.Project, error)
+	// ListSessionsOn lists sessions of the server of another socket, nil for the socket of the multiplexer
+	ListSessionsOn(Socket) ([]project.Project, error)
 	KillSession(project.Project) error
+	ResolveSessionName(project.Project) (SessionName, error)
+	// CurrentSession returns session the user is in, empty if not inside multiplexer
+	CurrentSession() (SessionName, error)
+	// PreviousSession returns session the user was in before the current one, empty if unknown
+	PreviousSession() (SessionName, error)
+	// SessionRoot returns the directory a running session was started in
+	SessionRoot(SessionName) (string, error)
 }
 
 type TmuxMultiplexer struct {
+	// ActiveTmuxSession is $TMUX of the client thop runs in, empty outside of tmux
 	ActiveTmuxSession string
 	Client            TmuxClient
+	// Socket of the server sessions are run on unless their template says otherwise, Client talks to it
+	Socket Socket
+	// KillFallback is what happens to the client when the session it's in gets killed, switch when not set
+	KillFallback config.KillFallback
 }
 
 type SessionName string
 
+// Session is a running tmux session
+type Session struct {
+	ID   string      `json:"id"`
+	Name SessionName `json:"name"`
+	// ProjectName is the name the session was created for, set only for sessions created by thop
+	ProjectName string       `json:"project_name,omitempty"`
+	UUID        project.UUID `json:"uuid,omitempty"`
+	// Hash of the template the session was built from
+	Hash     string    `json:"hash,omitempty"`
+	Attached int       `json:"attached"` // number of attached clients
+	Created  time.Time `json:"created"`
+	Activity time.Time `json:"activity"`
+	Windows  int       `json:"windows"`
+	Path     string    `json:"path"`
+}
+
+// session user options thop tags its sessions with
+const (
+	// ProjectNameOption holds the name the session was created for, which may differ from the sanitized one
+	ProjectNameOption = "@thop_name"
+	// UUIDOption holds UUID of the template the session was created from
+	UUIDOption = "@thop_uuid"
+	// HashOption holds hash of the template the session was built from
+	HashOption = "@thop_hash"
+)
+
+// SanitizeSessionName replaces characters tmux doesn't allow in session names, the same way tmux does,
+// so the name thop looks sessions up by is the name tmux created them with
+func SanitizeSessionName(name string) SessionName {
+	return SessionName(strings.NewReplacer(".", "_", ":", "_").Replace(name))
+}
+
 func (m *TmuxMultiplexer) AttachProject(p project.Project) error {
+	m = m.on(p)
+	sessionName, err := resolveSessionName(p)
+	if err != nil {
+		return err
+	}
+
+	if err := m.ensureSession(sessionName, p); err != nil {
+		return err
+	}
+
+	if m.inside() {
+		fmt.Println("Switching to", sessionName, "session")
+		if err := m.Client.SwitchSession(sessionName); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println("Attaching to", sessionName, "session")
+		if err := m.Client.AttachSession(sessionName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StartProject builds the session in the background if it doesn't exist yet, without attaching to it
+func (m *TmuxMultiplexer) StartProject(p project.Project) error {
+	m = m.on(p)
 	sessionName, err := resolveSessionName(p)
 	if err != nil {
 		return err
 	}
 
+	return m.ensureSession(sessionName, p)
+}
+
+// rebuilt session is assembled under a temporary name first, so the old one keeps running until it's replaced
+const rebuildSuffix = "_thop_rebuild"
+
+// RebuildProject builds a fresh session next to the running one and swaps them, so the client
+// is moved over to the new session instead of being detached when the old one is killed
+func (m *TmuxMultiplexer) RebuildProject(p project.Project) error {
+	m = m.on(p)
+	sessionName, err := resolveSessionName(p)
+	if err != nil {
+		return err
+	}
+
+	if p.Type == project.TypeTmuxSession {
+		return ErrTriedToBuildFromActiveSession.WithMsg("cannot rebuild session ", sessionName, " without a template")
+	}
+
 	sessionExists, err := m.Client.HasSession(sessionName)
 	if err != nil {
 		return err
 	}
 
 	if !sessionExists {
-		if p.Type == project.TypeTmuxSession {
-			return ErrTriedToBuildFromActiveSession.WithMsg("cannot build from active session (it was probably killed while thop was running)")
+		return m.AttachProject(p)
+	}
+
+	building := sessionName + rebuildSuffix
+	// until the original session is killed, it's still there to fall back to
+	discard := func(err error) error {
+		_ = m.Client.KillSession(building)
+		return err
+	}
+
+	if err := m.assembleSession(building, p); err != nil {
+		return discard(err)
+	}
+
+	if m.inside() {
+		if err := m.Client.SwitchSession(building); err != nil {
+			return discard(err)
 		}
-		if err := m.assembleSession(sessionName, p); err != nil {
+	}
+
+	if err := m.Client.KillSession(sessionName); err != nil {
+		if m.inside() {
+			_ = m.Client.SwitchSession(sessionName)
+		}
+		return discard(err)
+	}
+
+	if err := m.Client.RenameSession(building, sessionName); err != nil {
+		// the original session is gone, so the rebuilt one is kept under its temporary name
+		return ErrFailedToRenameSession.WithMsg("session rebuilt as ", building, ", but it couldn't be renamed: ", err.Error())
+	}
+
+	fmt.Println("Session", sessionName, "rebuilt")
+
+	if !m.inside() {
+		fmt.Println("Attaching to", sessionName, "session")
+		return m.Client.AttachSession(sessionName)
+	}
+
+	return nil
+}
+
+// UpdateProject brings template changes into the running session without touching what's already there,
+// windows are matched by name and only panes beyond the ones the window already has are added
+func (m *TmuxMultiplexer) UpdateProject(p project.Project) error {
+	m = m.on(p)
+	sessionName, err := resolveSessionName(p)
+	if err != nil {
+		return err
+	}
+
+	if p.Type == project.TypeTmuxSession {
+		return ErrTriedToBuildFromActiveSession.WithMsg("cannot update session ", sessionName, " without a template")
+	}
+
+	sessionExists, err := m.Client.HasSession(sessionName)
+	if err != nil {
+		return err
+	}
+
+	if sessionExists {
+		if err := m.extendSession(sessionName, p); err != nil {
 			return err
 		}
 	}
 
-	if m.ActiveTmuxSession != "" {
-		fmt.Println("Switching to", sessionName, "session")
-		if err := m.Client.SwitchSession(sessionName); err != nil {
+	return m.AttachProject(p)
+}
+
+func (m *TmuxMultiplexer) extendSession(sessionName SessionName, p project.Project) error {
+	existing, err := m.Client.ListWindows(sessionName)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range p.Template.Windows {
+		i := slices.IndexFunc(existing, func(sw SessionWindow) bool { return sw.Name == w.Name })
+		if i != -1 {
+			if err := m.assemblePanes(sessionName, p, w, existing[i].Panes); err != nil {
+				return err
+			}
+			// windows with the same name are matched one to one
+			existing = slices.Delete(existing, i, i+1)
+			continue
+		}
+
+		if err := m.Client.NewWindow(sessionName, p.Template.Root, w.Name, initialRoot(w)); err != nil {
 			return err
 		}
-	} else {
-		fmt.Println("Attaching to", sessionName, "session")
-		if err := m.Client.AttachSession(sessionName); err != nil {
+
+		if err := m.assembleWindow(sessionName, p, w); err != nil {
 			return err
 		}
+
+		fmt.Println("Window", w.Name, "added to", sessionName, "session")
 	}
 
 	return nil
 }
 
 func (m *TmuxMultiplexer) ListActiveSessions() ([]project.Project, error) {
-	if !m.Client.IsTmuxServerRunning() {
-		return []project.Project(nil), nil
+	return m.listSessions("")
+}
+
+func (m *TmuxMultiplexer) ListSessionsOn(socket Socket) ([]project.Project, error) {
+	if socket == m.Socket {
+		return nil, nil
 	}
 
-	sessionNames, err := m.Client.ListSessions()
+	return (&TmuxMultiplexer{Client: m.Client.OnSocket(socket)}).listSessions(socket)
+}
+
+// listSessions lists sessions of the multiplexer's server, labeled with the socket they were listed from
+func (m *TmuxMultiplexer) listSessions(label Socket) ([]project.Project, error) {
+	sessions, err := m.Client.ListSessions()
 	if err != nil {
 		return nil, err
 	}
 
 	var tmuxProjects []project.Project
-	for _, sessionName := range sessionNames {
-		tmuxProjects = append(tmuxProjects, project.Project{Name: project.Name(sessionName), Type: project.TypeTmuxSession})
+	for _, session := range sessions {
+		// sessions are shown under the name they were created for, it resolves back to the same session
+		name := project.Name(session.Name)
+		if session.ProjectName != "" {
+			name = project.Name(session.ProjectName)
+		}
+
+		tmuxProjects = append(tmuxProjects, project.Project{
+			Name: name,
+			Type: project.TypeTmuxSession,
+			Session: &project.Session{
+				Name:     string(session.Name),
+				Attached: session.Attached,
+				Windows:  session.Windows,
+				Activity: session.Activity,
+				Path:     session.Path,
+				Managed:  session.ProjectName != "",
+				UUID:     session.UUID,
+				Hash:     session.Hash,
+				Socket:   string(label),
+			},
+		})
 	}
 
 	return tmuxProjects, nil
 }
 
+// KillSession kills the session, when the client is in the killed session it's switched to another
+// one or detached first (depending on KillFallback), otherwise tmux would drop the user out abruptly
 func (m *TmuxMultiplexer) KillSession(p project.Project) error {
+	m = m.on(p)
 	sessionName, err := resolveSessionName(p)
 	if err != nil {
 		return err
 	}
 
+	if err := m.leaveSession(sessionName); err != nil {
+		return err
+	}
+
 	if err := m.Client.KillSession(sessionName); err != nil {
 		return err
 	}
@@ -84,6 +304,141 @@ func (m *TmuxMultiplexer) KillSession(p project.Project) error {
 	return nil
 }
 
+// leaveSession switches the client to the previous session, or any other one, when it's in the given session,
+// the client is detached instead when configured so or when there is no other session
+func (m *TmuxMultiplexer) leaveSession(sessionName SessionName) error {
+	current, err := m.CurrentSession()
+	if err != nil || current != sessionName {
+		return err
+	}
+
+	if m.KillFallback == config.FallbackDetach {
+		return m.Client.DetachClient()
+	}
+
+	target, err := m.PreviousSession()
+	if err != nil {
+		return err
+	}
+
+	if target != "" && target != sessionName {
+		// previous session may have been killed just before, e.g. when killing in bulk
+		if exists, err := m.Client.HasSession(target); err != nil || !exists {
+			target = ""
+		}
+	}
+
+	if target == "" || target == sessionName {
+		sessions, err := m.Client.ListSessions()
+		if err != nil {
+			return err
+		}
+
+		i := slices.IndexFunc(sessions, func(s Session) bool { return s.Name != sessionName })
+		if i == -1 {
+			// nothing to switch to, the last session is going away
+			return m.Client.DetachClient()
+		}
+		target = sessions[i].Name
+	}
+
+	fmt.Println("Switching to", target, "session")
+	return m.Client.SwitchSession(target)
+}
+
+// socketOf returns socket of the server the project's session runs on
+func (m *TmuxMultiplexer) socketOf(p project.Project) Socket {
+	if p.Template.Socket != "" {
+		return Socket(p.Template.Socket)
+	}
+	if p.Session != nil && p.Session.Socket != "" {
+		return Socket(p.Session.Socket)
+	}
+	return m.Socket
+}
+
+// on returns the multiplexer running the project on the server of its socket
+func (m *TmuxMultiplexer) on(p project.Project) *TmuxMultiplexer {
+	socket := m.socketOf(p)
+	if socket == m.Socket {
+		return m
+	}
+
+	on := *m
+	on.Socket = socket
+	on.Client = m.Client.OnSocket(socket)
+	return &on
+}
+
+// inside tells whether thop runs in a client of the multiplexer's server, so sessions are switched instead of attached
+func (m *TmuxMultiplexer) inside() bool {
+	return m.ActiveTmuxSession != "" && m.Socket.Serves(m.ActiveTmuxSession)
+}
+
+func (m *TmuxMultiplexer) ResolveSessionName(p project.Project) (SessionName, error) {
+	return resolveSessionName(p)
+}
+
+func (m *TmuxMultiplexer) CurrentSession() (SessionName, error) {
+	if !m.inside() {
+		return "", nil
+	}
+
+	name, err := m.Client.DisplayMessage("#S")
+	return SessionName(name), err
+}
+
+func (m *TmuxMultiplexer) PreviousSession() (SessionName, error) {
+	if !m.inside() {
+		return "", nil
+	}
+
+	name, err := m.Client.DisplayMessage("#{client_last_session}")
+	return SessionName(name), err
+}
+
+func (m *TmuxMultiplexer) SessionRoot(sessionName SessionName) (string, error) {
+	return m.Client.SessionPath(sessionName)
+}
+
+func (m *TmuxMultiplexer) ensureSession(sessionName SessionName, p project.Project) error {
+	sessionExists, err := m.Client.HasSession(sessionName)
+	if err != nil {
+		return err
+	}
+
+	if sessionExists {
+		return nil
+	}
+
+	if p.Type == project.TypeTmuxSession {
+		return ErrTriedToBuildFromActiveSession.WithMsg("cannot build from active session (it was probably killed while thop was running)")
+	}
+
+	if err := m.assembleSession(sessionName, p); err != nil {
+		return err
+	}
+
+	fmt.Println("Session", sessionName, "created")
+	return nil
+}
+
+func (m *TmuxMultiplexer) Plan(p project.Project) ([][]string, error) {
+	sessionName, err := resolveSessionName(p)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder := &executor.RecordingExecutor{}
+	planner := TmuxMultiplexer{Client: &TmuxClientImpl{E: recorder, Socket: m.socketOf(p)}}
+
+	if err := planner.assembleSession(sessionName, p); err != nil {
+		return nil, err
+	}
+
+	return recorder.Commands, nil
+}
+
 func (m *TmuxMultiplexer) assembleSession(sessionName SessionName, p project.Project) error {
 	sessionRoot := p.Template.Root
 	if sessionRoot == "" {
@@ -94,48 +449,121 @@ func (m *TmuxMultiplexer) assembleSession(sessionName SessionName, p project.Pro
 		return ErrInvalidTemplateArgs.WithMsg("project template needs at least one window to be created")
 	}
 
-	mainWindow := p.Template.Windows[0]
+	for i, w := range p.Template.Windows {
+		var err error
+		if i == 0 {
+			// first window gets created together with the session
+			err = m.Client.NewSession(sessionName, sessionRoot, w.Name, initialRoot(w), p.Template.Env)
+		} else {
+			err = m.Client.NewWindow(sessionName, sessionRoot, w.Name, initialRoot(w))
+		}
+		if err != nil {
+			return err
+		}
 
-	// first window gets created together with the session
-	err := m.Client.NewSession(sessionName, sessionRoot, mainWindow.Name, mainWindow.Root)
-	if err != nil {
-		return err
+		if i == 0 {
+			if err := m.tagSession(sessionName, p); err != nil {
+				return err
+			}
+		}
+
+		if err := m.assembleWindow(sessionName, p, w); err != nil {
+			return err
+		}
 	}
 
-	for i, window := range p.Template.Windows {
-		// main window is already created, so skip it
+	return nil
+}
+
+// marks the session as created by thop, storing the original name so sanitized sessions
+// can be listed under it, and the template UUID and hash so the session can be matched with
+// the template later and told whether the template changed since
+func (m *TmuxMultiplexer) tagSession(sessionName SessionName, p project.Project) error {
+	options := []Option{{Name: ProjectNameOption, Value: unsanitizedSessionName(p)}}
+	if p.UUID != "" {
+		options = append(options,
+			Option{Name: UUIDOption, Value: string(p.UUID)},
+			Option{Name: HashOption, Value: p.Template.Hash()},
+		)
+	}
+
+	return m.Client.SetSessionOptions(sessionName, options)
+}
+
+// first pane of the window is created together with it, so it starts at the pane's root
+func initialRoot(w window.Window) window.Root {
+	if len(w.Panes) > 0 && w.Panes[0].Root != "" {
+		return window.Root(w.Panes[0].Root)
+	}
+	return w.Root
+}
+
+func (m *TmuxMultiplexer) assembleWindow(sessionName SessionName, p project.Project, w window.Window) error {
+	return m.assemblePanes(sessionName, p, w, 0)
+}
+
+// assemblePanes sets up panes of the window starting at index from, earlier panes are expected to exist already
+func (m *TmuxMultiplexer) assemblePanes(sessionName SessionName, p project.Project, w window.Window, from int) error {
+	// window without panes still has a single one, it just has no commands of its own
+	panes := w.Panes
+	if len(panes) == 0 {
+		panes = []pane.Pane{{}}
+	}
+
+	for i, pn := range panes {
+		if i < from {
+			continue
+		}
+
 		if i != 0 {
-			err := m.Client.NewWindow(sessionName, sessionRoot, window.Name, window.Root)
-			if err != nil {
-				return err
+			paneRoot := pn.Root
+			if paneRoot == "" {
+				paneRoot = pane.Root(w.Root)
+			}
+			if paneRoot == "" {
+				paneRoot = pane.Root(p.Template.Root)
 			}
-		}
 
-		for _, keys := range p.Template.Commands {
-			if err := m.Client.SendKeys(sessionName, window.Name, keys); err != nil {
+			if err := m.Client.SplitWindow(sessionName, w.Name, paneRoot); err != nil {
 				return err
 			}
+
+			// applied after every split, otherwise tmux runs out of space for new panes
+			if w.Layout != "" {
+				if err := m.Client.SelectLayout(sessionName, w.Name, w.Layout); err != nil {
+					return err
+				}
+			}
 		}
 
-		for _, keys := range window.Commands {
-			if err := m.Client.SendKeys(sessionName, window.Name, keys); err != nil {
+		commands := slices.Concat(p.Template.Commands, w.Commands, pn.Commands)
+		for _, keys := range commands {
+			if err := m.Client.SendKeys(sessionName, w.Name, keys); err != nil {
 				return err
 			}
 		}
 	}
 
-	fmt.Println("Session", sessionName, "created")
 	return nil
 }
 
 func resolveSessionName(p project.Project) (SessionName, error) {
-	if p.Template.Name != "" {
-		return SessionName(p.Template.Name), nil
+	if p.Session != nil && p.Session.Name != "" {
+		// running session may have been renamed, while it still carries the name it was created for
+		return SessionName(p.Session.Name), nil
 	}
 
-	if p.Name == "" {
+	name := unsanitizedSessionName(p)
+	if name == "" {
 		return "", ErrInvalidTemplateArgs.WithMsg("project name cannot be empty")
 	}
 
-	return SessionName(p.Name), nil
+	return SanitizeSessionName(name), nil
+}
+
+func unsanitizedSessionName(p project.Project) string {
+	if p.Template.Name != "" {
+		return string(p.Template.Name)
+	}
+	return string(p.Name)
 }
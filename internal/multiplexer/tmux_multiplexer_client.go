@@ -2,6 +2,7 @@ package multiplexer
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"slices"
@@ -9,6 +10,7 @@ import (
 	"thop/internal/executor"
 	"thop/internal/problem"
 	"thop/internal/types/command"
+	"thop/internal/types/pane"
 	"thop/internal/types/template"
 	"thop/internal/types/window"
 )
@@ -16,45 +18,61 @@ import (
 type TmuxClient interface {
 	AttachSession(SessionName) error
 	SwitchSession(SessionName) error
+	// DetachClient detaches the client the command runs in
+	DetachClient() error
 	HasSession(SessionName) (bool, error)
-	NewSession(SessionName, template.Root, window.Name, window.Root) error
+	NewSession(SessionName, template.Root, window.Name, window.Root, template.Env) error
 	NewWindow(SessionName, template.Root, window.Name, window.Root) error
+	SplitWindow(SessionName, window.Name, pane.Root) error
+	SelectLayout(SessionName, window.Name, window.Layout) error
 	SendKeys(SessionName, window.Name, command.Command) error
-	ListSessions() ([]SessionName, error)
-	IsTmuxServerRunning() bool
+	ListSessions() ([]Session, error)
+	ListWindows(SessionName) ([]SessionWindow, error)
 	KillSession(SessionName) error
+	RenameSession(from SessionName, to SessionName) error
+	SetSessionOptions(SessionName, []Option) error
+	DisplayMessage(format string) (string, error)
+	SessionPath(SessionName) (string, error)
+	// OnSocket returns the client running its commands against the server of the socket
+	OnSocket(Socket) TmuxClient
 }
 
 type TmuxClientImpl struct {
-	E executor.CommandExecutor
+	E      executor.CommandExecutor
+	Socket Socket
 }
 
 const (
 	ErrFailedToAttachSession         problem.Key = "TMUX_FAILED_TO_ATTACH_SESSION"
 	ErrFailedToSwitchSession         problem.Key = "TMUX_FAILED_TO_SWITCH_SESSION"
+	ErrFailedToDetachClient          problem.Key = "TMUX_FAILED_TO_DETACH_CLIENT"
 	ErrFailedToCheckSession          problem.Key = "TMUX_FAILED_TO_CHECK_SESSION"
 	ErrFailedToCreateSession         problem.Key = "TMUX_FAILED_TO_CREATE_SESSION"
 	ErrFailedToCreateWindow          problem.Key = "TMUX_FAILED_TO_CREATE_WINDOW"
+	ErrFailedToSplitWindow           problem.Key = "TMUX_FAILED_TO_SPLIT_WINDOW"
+	ErrFailedToSelectLayout          problem.Key = "TMUX_FAILED_TO_SELECT_LAYOUT"
 	ErrFailedToListSessions          problem.Key = "TMUX_FAILED_TO_LIST_SESSIONS"
+	ErrFailedToListWindows           problem.Key = "TMUX_FAILED_TO_LIST_WINDOWS"
 	ErrFailedToKillSession           problem.Key = "TMUX_FAILED_TO_KILL_SESSION"
+	ErrFailedToRenameSession         problem.Key = "TMUX_FAILED_TO_RENAME_SESSION"
 	ErrFailedToSendKeys              problem.Key = "TMUX_FAILED_TO_SEND_KEYS"
+	ErrFailedToDisplayMessage        problem.Key = "TMUX_FAILED_TO_DISPLAY_MESSAGE"
+	ErrFailedToSetOption             problem.Key = "TMUX_FAILED_TO_SET_OPTION"
 	ErrTriedToBuildFromActiveSession problem.Key = "TMUX_TRIED_TO_BUILD_FROM_ACTIVE_SESSION"
 	ErrInvalidTemplateArgs           problem.Key = "TMUX_INVALID_TEMPLATE_ARGS"
 )
 
-func (c *TmuxClientImpl) IsTmuxServerRunning() bool {
-	cmd := exec.Command("tmux", "run")
-	_, _, err := c.E.Execute(cmd)
-	return err == nil
-}
-
 func (c *TmuxClientImpl) AttachSession(session SessionName) error {
 	if session == "" {
 		return ErrInvalidTemplateArgs.WithMsg("session name cannot be empty")
 	}
 
-	cmd := exec.Command("tmux", "attach", "-t", string(session))
+	cmd := c.command("attach", "-t", string(session))
 	cmd.Stdin = os.Stdin // bind tmux session to terminal
+	if c.Socket != "" {
+		// attach is used from inside of tmux only when the session lives on another server
+		cmd.Env = slices.DeleteFunc(os.Environ(), func(v string) bool { return strings.HasPrefix(v, "TMUX=") })
+	}
 
 	_, _, err := c.E.Execute(cmd)
 	if err != nil {
@@ -69,7 +87,7 @@ func (c *TmuxClientImpl) SwitchSession(session SessionName) error {
 		return ErrInvalidTemplateArgs.WithMsg("session name cannot be empty")
 	}
 
-	cmd := exec.Command("tmux", "switch", "-t", string(session))
+	cmd := c.command("switch", "-t", string(session))
 
 	_, _, err := c.E.Execute(cmd)
 	if err != nil {
@@ -79,12 +97,22 @@ func (c *TmuxClientImpl) SwitchSession(session SessionName) error {
 	return nil
 }
 
+func (c *TmuxClientImpl) DetachClient() error {
+	cmd := c.command("detach-client")
+
+	if _, _, err := c.E.Execute(cmd); err != nil {
+		return ErrFailedToDetachClient.WithMsg(err.Error())
+	}
+
+	return nil
+}
+
 func (c *TmuxClientImpl) HasSession(session SessionName) (bool, error) {
 	if session == "" {
 		return false, ErrInvalidTemplateArgs.WithMsg("session name cannot be empty")
 	}
 
-	cmd := exec.Command("tmux", "has-session", "-t", string(session))
+	cmd := c.command("has-session", "-t", exactSession(session))
 
 	_, exitCode, err := c.E.Execute(cmd)
 	if err != nil {
@@ -103,16 +131,22 @@ func (c *TmuxClientImpl) NewSession(
 	root template.Root,
 	windowName window.Name,
 	windowRoot window.Root,
+	env template.Env,
 ) error {
 	if anyEmpty(string(session), string(root), string(windowName)) {
 		return ErrInvalidTemplateArgs.WithMsg("session, root and window name cannot be empty")
 	}
 
-	cmd := exec.Command("tmux", "new-session", "-d")
+	cmd := c.command("new-session", "-d")
 	cmd.Args = append(cmd.Args, "-s", string(session))
 	cmd.Args = append(cmd.Args, "-c", string(root))
 	cmd.Args = append(cmd.Args, "-n", string(windowName))
 
+	// sorted, so the same template always results in the same command
+	for _, key := range slices.Sorted(maps.Keys(env)) {
+		cmd.Args = append(cmd.Args, "-e", fmt.Sprintf("%s=%s", key, env[key]))
+	}
+
 	if windowRoot != "" {
 		// little hack to start first window at different root than session
 		cmd.Args = append(cmd.Args, fmt.Sprintf("cd %s && exec $SHELL", windowRoot))
@@ -135,7 +169,7 @@ func (c *TmuxClientImpl) NewWindow(
 		return ErrInvalidTemplateArgs.WithMsg("session, root and window name cannot be empty")
 	}
 
-	cmd := exec.Command("tmux", "new-window", "-d")
+	cmd := c.command("new-window", "-d")
 	cmd.Args = append(cmd.Args, "-t", string(session))
 	cmd.Args = append(cmd.Args, "-n", string(windowName))
 
@@ -155,6 +189,47 @@ func (c *TmuxClientImpl) NewWindow(
 
 }
 
+// SplitWindow adds a pane to the window, the new pane becomes active so following keys are sent to it
+func (c *TmuxClientImpl) SplitWindow(
+	session SessionName,
+	windowName window.Name,
+	root pane.Root,
+) error {
+	if anyEmpty(string(session), string(windowName), string(root)) {
+		return ErrInvalidTemplateArgs.WithMsg("session, window name and root cannot be empty")
+	}
+
+	cmd := c.command("split-window")
+	cmd.Args = append(cmd.Args, "-t", fmt.Sprintf("%s:%s", session, windowName))
+	cmd.Args = append(cmd.Args, "-c", string(root))
+
+	if _, _, err := c.E.Execute(cmd); err != nil {
+		return ErrFailedToSplitWindow.WithMsg(err.Error())
+	}
+
+	return nil
+}
+
+func (c *TmuxClientImpl) SelectLayout(
+	session SessionName,
+	windowName window.Name,
+	layout window.Layout,
+) error {
+	if anyEmpty(string(session), string(windowName), string(layout)) {
+		return ErrInvalidTemplateArgs.WithMsg("session, window name and layout cannot be empty")
+	}
+
+	cmd := c.command("select-layout")
+	cmd.Args = append(cmd.Args, "-t", fmt.Sprintf("%s:%s", session, windowName))
+	cmd.Args = append(cmd.Args, string(layout))
+
+	if _, _, err := c.E.Execute(cmd); err != nil {
+		return ErrFailedToSelectLayout.WithMsg(err.Error())
+	}
+
+	return nil
+}
+
 func (c *TmuxClientImpl) SendKeys(
 	session SessionName,
 	windowName window.Name,
@@ -164,7 +239,7 @@ func (c *TmuxClientImpl) SendKeys(
 		return ErrInvalidTemplateArgs.WithMsg("session, window name and keys cannot be empty")
 	}
 
-	cmd := exec.Command("tmux", "send-keys")
+	cmd := c.command("send-keys")
 
 	// tmux needs combined name of session:window to send keys to
 	cmd.Args = append(cmd.Args, "-t", fmt.Sprintf("%s:%s", session, windowName))
@@ -178,21 +253,41 @@ func (c *TmuxClientImpl) SendKeys(
 	return nil
 }
 
-func (c *TmuxClientImpl) ListSessions() ([]SessionName, error) {
-	cmd := exec.Command("tmux", "list-sessions", "-F", "#S")
+// ListSessions lists running sessions, no running server simply means there are none
+func (c *TmuxClientImpl) ListSessions() ([]Session, error) {
+	// -u stops tmux from replacing the delimiter (and non-ASCII characters) with _ when the locale isn't UTF-8
+	cmd := c.command("-u", "list-sessions", "-F", sessionFormat)
 
 	output, _, err := c.E.Execute(cmd)
 	if err != nil {
+		if isNoServerRunning(err) {
+			return nil, nil
+		}
 		return nil, ErrFailedToListSessions.WithMsg(err.Error())
 	}
 
-	var sessionNames []SessionName
-	for line := range strings.SplitSeq(output, "\n") {
-		sessionNames = append(sessionNames, SessionName(line))
+	return parseSessions(output)
+}
+
+// SessionWindow is a window of a running session
+type SessionWindow struct {
+	Name  window.Name
+	Panes int
+}
+
+func (c *TmuxClientImpl) ListWindows(session SessionName) ([]SessionWindow, error) {
+	if session == "" {
+		return nil, ErrInvalidTemplateArgs.WithMsg("session name cannot be empty")
 	}
 
-	// drop the last one, it's empty
-	return sessionNames[:len(sessionNames)-1], nil
+	cmd := c.command("-u", "list-windows", "-t", exactSession(session)+":", "-F", windowFormat)
+
+	output, _, err := c.E.Execute(cmd)
+	if err != nil {
+		return nil, ErrFailedToListWindows.WithMsg(err.Error())
+	}
+
+	return parseWindows(output)
 }
 
 func (c *TmuxClientImpl) KillSession(session SessionName) error {
@@ -200,7 +295,7 @@ func (c *TmuxClientImpl) KillSession(session SessionName) error {
 		return ErrInvalidTemplateArgs.WithMsg("session name cannot be empty")
 	}
 
-	cmd := exec.Command("tmux", "kill-session", "-t", string(session))
+	cmd := c.command("kill-session", "-t", exactSession(session))
 
 	_, _, err := c.E.Execute(cmd)
 	if err != nil {
@@ -210,6 +305,98 @@ func (c *TmuxClientImpl) KillSession(session SessionName) error {
 	return nil
 }
 
+func (c *TmuxClientImpl) RenameSession(from SessionName, to SessionName) error {
+	if anyEmpty(string(from), string(to)) {
+		return ErrInvalidTemplateArgs.WithMsg("session names cannot be empty")
+	}
+
+	cmd := c.command("rename-session", "-t", exactSession(from), string(to))
+
+	if _, _, err := c.E.Execute(cmd); err != nil {
+		return ErrFailedToRenameSession.WithMsg(err.Error())
+	}
+
+	return nil
+}
+
+// Option is a tmux option, user options (starting with @) can hold any value
+type Option struct {
+	Name  string
+	Value string
+}
+
+// SetSessionOptions sets all options of the session at once, chained into a single tmux command
+func (c *TmuxClientImpl) SetSessionOptions(session SessionName, options []Option) error {
+	if session == "" || len(options) == 0 {
+		return ErrInvalidTemplateArgs.WithMsg("session name and options cannot be empty")
+	}
+
+	cmd := c.command()
+	for i, option := range options {
+		if option.Name == "" {
+			return ErrInvalidTemplateArgs.WithMsg("option name cannot be empty")
+		}
+		if i > 0 {
+			cmd.Args = append(cmd.Args, ";")
+		}
+		cmd.Args = append(cmd.Args, "set-option", "-t", exactSession(session)+":", option.Name, option.Value)
+	}
+
+	if _, _, err := c.E.Execute(cmd); err != nil {
+		return ErrFailedToSetOption.WithMsg(err.Error())
+	}
+
+	return nil
+}
+
+// DisplayMessage expands tmux format in context of the current client
+func (c *TmuxClientImpl) DisplayMessage(format string) (string, error) {
+	cmd := c.command("-u", "display-message", "-p", format)
+
+	output, _, err := c.E.Execute(cmd)
+	if err != nil {
+		return "", ErrFailedToDisplayMessage.WithMsg(err.Error())
+	}
+
+	return strings.TrimSuffix(output, "\n"), nil
+}
+
+// SessionPath returns the directory the session was started in
+func (c *TmuxClientImpl) SessionPath(session SessionName) (string, error) {
+	if session == "" {
+		return "", ErrInvalidTemplateArgs.WithMsg("session name cannot be empty")
+	}
+
+	cmd := c.command("-u", "display-message", "-p", "-t", exactSession(session)+":", "#{session_path}")
+
+	output, _, err := c.E.Execute(cmd)
+	if err != nil {
+		return "", ErrFailedToDisplayMessage.WithMsg(err.Error())
+	}
+
+	return strings.TrimSuffix(output, "\n"), nil
+}
+
+func (c *TmuxClientImpl) OnSocket(socket Socket) TmuxClient {
+	return &TmuxClientImpl{E: c.E, Socket: socket}
+}
+
+// command builds tmux command talking to the server of the client's socket
+func (c *TmuxClientImpl) command(args ...string) *exec.Cmd {
+	return exec.Command("tmux", append(c.Socket.args(), args...)...)
+}
+
+// tmux matches session targets by prefix when there is no exact match, = disables that
+func exactSession(session SessionName) string {
+	return "=" + string(session)
+}
+
+// tmux reports missing server differently depending on whether the socket file exists
+func isNoServerRunning(err error) bool {
+	stderr := executor.Stderr(err)
+	return strings.Contains(stderr, "no server running") || strings.Contains(stderr, "error connecting to")
+}
+
 func anyEmpty(s ...string) bool {
 	return slices.Contains(s, "")
 }
@@ -0,0 +1,19 @@
+package output
+
+import (
+	"io"
+	"thop/internal/bundle"
+)
+
+func WriteBundle(w io.Writer, b bundle.Bundle) error {
+	bytes, err := b.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(bytes); err != nil {
+		return ErrFailedToWrite.WithMsg(err.Error())
+	}
+
+	return nil
+}
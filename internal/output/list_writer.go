@@ -0,0 +1,157 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"thop/internal/problem"
+	"thop/internal/service"
+	"thop/internal/types/project"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatTable Format = "table"
+	FormatTSV   Format = "tsv"
+	FormatJSON  Format = "json"
+	// go template is passed after the prefix, e.g. go-template={{.Name}}
+	FormatGoTemplate Format = "go-template="
+)
+
+const (
+	ErrUnknownFormat   problem.Key = "OUTPUT_UNKNOWN_FORMAT"
+	ErrInvalidTemplate problem.Key = "OUTPUT_INVALID_TEMPLATE"
+	ErrFailedToWrite   problem.Key = "OUTPUT_FAILED_TO_WRITE"
+)
+
+// WriteList renders list items in given format
+func WriteList(w io.Writer, format Format, items []service.ListItem) error {
+	var err error
+
+	switch {
+	case format == FormatTable:
+		err = writeTable(w, items)
+	case format == FormatTSV:
+		err = writeTSV(w, items)
+	case format == FormatJSON:
+		err = writeJSON(w, items)
+	case strings.HasPrefix(string(format), string(FormatGoTemplate)):
+		return writeGoTemplate(w, strings.TrimPrefix(string(format), string(FormatGoTemplate)), items)
+	default:
+		return ErrUnknownFormat.WithMsg("unknown format ", format, ", expected one of: table, tsv, json, go-template=<template>")
+	}
+
+	if err != nil {
+		return ErrFailedToWrite.WithMsg(err.Error())
+	}
+
+	return nil
+}
+
+func writeTable(w io.Writer, items []service.ListItem) error {
+	now := time.Now()
+
+	// socket matters only when sessions of more than one server are listed
+	withSocket := slices.ContainsFunc(items, func(item service.ListItem) bool { return item.Socket != "" })
+	// same for errors, which only broken templates have
+	withError := slices.ContainsFunc(items, func(item service.ListItem) bool { return item.Error != "" })
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "NAME\tSESSION\tRUNNING\tATTACHED\tIDLE\tWINDOWS\tROOT\tUUID"
+	if withSocket {
+		header += "\tSOCKET"
+	}
+	if withError {
+		header += "\tERROR"
+	}
+	fmt.Fprintln(tw, header)
+
+	for _, item := range items {
+		idle := ""
+		if item.Activity != nil {
+			idle = (&project.Session{Activity: *item.Activity}).Idle(now)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s",
+			item.Name, item.SessionName, yesNo(item.Running), yesNo(item.Attached), orDash(idle),
+			item.Windows, orDash(string(item.Root)), orDash(string(item.UUID)))
+		if withSocket {
+			fmt.Fprintf(tw, "\t%s", orDash(item.Socket))
+		}
+		if withError {
+			fmt.Fprintf(tw, "\t%s", orDash(item.Error))
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// no header and no padding, so it's easy to consume with cut/awk
+func writeTSV(w io.Writer, items []service.ListItem) error {
+	for _, item := range items {
+		// activity is a unix timestamp, 0 when the session is not running
+		var activity int64
+		if item.Activity != nil {
+			activity = item.Activity.Unix()
+		}
+
+		_, err := fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%d\t%s\t%t\t%d\t%s\t%t\t%t\t%s\t%s\n",
+			item.UUID, item.Name, item.SessionName, item.Running, item.Windows, item.Root,
+			item.Attached, activity, item.SessionPath, item.Managed, item.Stale, item.Socket, item.Error)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeJSON(w io.Writer, items []service.ListItem) error {
+	if items == nil {
+		// print empty array instead of null
+		items = []service.ListItem{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(items)
+}
+
+func writeGoTemplate(w io.Writer, text string, items []service.ListItem) error {
+	tmpl, err := template.New("list").Parse(text)
+	if err != nil {
+		return ErrInvalidTemplate.WithMsg(err.Error())
+	}
+
+	for _, item := range items {
+		if err := tmpl.Execute(w, item); err != nil {
+			return ErrInvalidTemplate.WithMsg(err.Error())
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return ErrFailedToWrite.WithMsg(err.Error())
+		}
+	}
+
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
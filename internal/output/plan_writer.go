@@ -0,0 +1,59 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"thop/internal/executor"
+	"thop/internal/service"
+
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	FormatYAML Format = "yaml"
+)
+
+// WritePlan renders resolved project in yaml or json, yaml output stays a valid template
+// with commands written down as comments
+func WritePlan(w io.Writer, format Format, plan service.ProjectPlan) error {
+	switch format {
+	case FormatYAML:
+		return writePlanYAML(w, plan)
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(plan); err != nil {
+			return ErrFailedToWrite.WithMsg(err.Error())
+		}
+		return nil
+	default:
+		return ErrUnknownFormat.WithMsg("unknown format ", format, ", expected one of: yaml, json")
+	}
+}
+
+func writePlanYAML(w io.Writer, plan service.ProjectPlan) error {
+	bytes, err := yaml.Marshal(plan.Project)
+	if err != nil {
+		return ErrFailedToWrite.WithMsg(err.Error())
+	}
+
+	var out strings.Builder
+	if plan.Project.UUID != "" {
+		fmt.Fprintf(&out, "# uuid: %s\n", plan.Project.UUID)
+	}
+	fmt.Fprintf(&out, "# session: %s\n", plan.SessionName)
+	out.Write(bytes)
+
+	out.WriteString("\n# tmux commands:\n")
+	for _, command := range plan.Commands {
+		fmt.Fprintf(&out, "# %s\n", executor.CommandLine(command))
+	}
+
+	if _, err := io.WriteString(w, out.String()); err != nil {
+		return ErrFailedToWrite.WithMsg(err.Error())
+	}
+
+	return nil
+}
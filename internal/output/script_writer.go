@@ -0,0 +1,137 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"thop/internal/executor"
+	"thop/internal/problem"
+	"thop/internal/service"
+)
+
+const (
+	FormatShell Format = "sh"
+)
+
+const (
+	ErrUnsupportedFormat problem.Key = "OUTPUT_UNSUPPORTED_FORMAT"
+)
+
+// WriteScript renders a standalone bash script recreating the session with plain tmux commands,
+// running it again attaches to the existing session instead of building a new one
+func WriteScript(w io.Writer, plan service.ProjectPlan) error {
+	var out strings.Builder
+
+	session := executor.CommandLine([]string{string(plan.SessionName)})
+
+	fmt.Fprintf(&out, "#!/usr/bin/env bash\n")
+	fmt.Fprintf(&out, "# Recreates %q tmux session, generated by thop\n", plan.Project.Name)
+	fmt.Fprintf(&out, "set -euo pipefail\n\n")
+	fmt.Fprintf(&out, "session=%s\n", session)
+
+	// session is checked and attached to on the same server the commands below build it on
+	tmux := "tmux"
+	if socket := socketArgs(plan.Commands); socket != nil {
+		fmt.Fprintf(&out, "tmux=(%s)\n", executor.CommandLine(append([]string{"tmux"}, socket...)))
+		tmux = `"${tmux[@]}"`
+	}
+	fmt.Fprintln(&out)
+
+	fmt.Fprintf(&out, "attach() {\n")
+	fmt.Fprintf(&out, "  if [ -n \"${TMUX:-}\" ]; then\n")
+	fmt.Fprintf(&out, "    %s switch-client -t \"=$session\"\n", tmux)
+	fmt.Fprintf(&out, "  else\n")
+	fmt.Fprintf(&out, "    %s attach-session -t \"=$session\"\n", tmux)
+	fmt.Fprintf(&out, "  fi\n")
+	fmt.Fprintf(&out, "}\n\n")
+
+	fmt.Fprintf(&out, "if %s has-session -t \"=$session\" 2>/dev/null; then\n", tmux)
+	fmt.Fprintf(&out, "  attach\n")
+	fmt.Fprintf(&out, "  exit 0\n")
+	fmt.Fprintf(&out, "fi\n\n")
+
+	for _, command := range plan.Commands {
+		fmt.Fprintln(&out, scriptLine(command))
+	}
+
+	fmt.Fprintf(&out, "\nattach\n")
+
+	if _, err := io.WriteString(w, out.String()); err != nil {
+		return ErrFailedToWrite.WithMsg(err.Error())
+	}
+
+	return nil
+}
+
+// socketArgs returns -L/-S arguments the planned tmux commands target their server with
+func socketArgs(commands [][]string) []string {
+	if len(commands) == 0 || len(commands[0]) < 3 {
+		return nil
+	}
+
+	if flag := commands[0][1]; flag == "-L" || flag == "-S" {
+		return commands[0][1:3]
+	}
+	return nil
+}
+
+// scriptLine quotes the command, except for roots (-c values), which may refer to home
+// or environment variables, those are left for the shell running the script to expand
+func scriptLine(command []string) string {
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		if i > 0 && command[i-1] == "-c" {
+			quoted[i] = expandablePath(arg)
+		} else {
+			quoted[i] = executor.CommandLine([]string{arg})
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// expandablePath double quotes the path, so leading ~ (as $HOME) and $VAR or ${VAR} in it are expanded,
+// anything else the shell would evaluate, like $(...) or backticks, is escaped
+func expandablePath(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.Contains(path, "$") {
+		return executor.CommandLine([]string{path})
+	}
+
+	var out strings.Builder
+	out.WriteString(`"`)
+
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		out.WriteString("$HOME")
+		path = path[1:]
+	}
+
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if strings.IndexByte("$\\\"`", c) != -1 && !(c == '$' && variableAt(path[i+1:])) {
+			out.WriteByte('\\')
+		}
+		out.WriteByte(c)
+	}
+
+	out.WriteString(`"`)
+	return out.String()
+}
+
+// variableAt tells whether the text after $ is a plain variable name, either bare or in braces
+func variableAt(s string) bool {
+	name := strings.TrimPrefix(s, "{")
+	braced := len(name) < len(s)
+
+	end := 0
+	for end < len(name) && (name[end] == '_' || isASCIILetter(name[end]) || (end > 0 && '0' <= name[end] && name[end] <= '9')) {
+		end++
+	}
+	if end == 0 {
+		return false
+	}
+
+	return !braced || strings.HasPrefix(name[end:], "}")
+}
+
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
@@ -0,0 +1,42 @@
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"thop/internal/problem"
+)
+
+type Prompter interface {
+	// Confirm asks a yes/no question, anything other than yes is taken as no
+	Confirm(question string) (bool, error)
+}
+
+type TerminalPrompter struct {
+	In  io.Reader
+	Out io.Writer
+}
+
+const (
+	ErrFailedToPrompt problem.Key = "PROMPT_FAILED"
+)
+
+func (p *TerminalPrompter) Confirm(question string) (bool, error) {
+	if _, err := fmt.Fprintf(p.Out, "%s [y/N] ", question); err != nil {
+		return false, ErrFailedToPrompt.WithMsg(err.Error())
+	}
+
+	answer, err := bufio.NewReader(p.In).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, ErrFailedToPrompt.WithMsg(err.Error())
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
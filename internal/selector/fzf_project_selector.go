@@ -2,20 +2,64 @@ package selector
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"slices"
 	"strings"
 	"thop/internal/executor"
 	"thop/internal/problem"
 	"thop/internal/types/project"
+	"time"
 )
 
 type ProjectSelector interface {
 	SelectFrom(items []project.Project, prompt string) (*project.Project, error)
+	SelectMany(items []project.Project, prompt string) ([]project.Project, error)
+	// SelectAction lets the user pick a project together with an action to perform on it,
+	// load is used to (re)fetch the items, since the list can be reloaded from inside the selector
+	SelectAction(load func() ([]project.Project, error), prompt string) (Selection, error)
+	// WriteEntries writes items in the format used by SelectAction, used for reloading the list
+	WriteEntries(w io.Writer, items []project.Project) error
 }
 
 type FzfProjectSelector struct {
 	E executor.CommandExecutor
+	// ReloadCommand is a shell command printing fresh entries, bound to ctrl-r when set
+	ReloadCommand string
+	// Order defaults to AlphabeticalOrder when not set
+	Order Ordering
+	// Now is used to tell how long sessions are idle, defaults to time.Now
+	Now func() time.Time
+}
+
+type Action string
+
+const (
+	ActionOpen   Action = "open"
+	ActionKill   Action = "kill"
+	ActionEdit   Action = "edit"
+	ActionDelete Action = "delete"
+	ActionNew    Action = "new"
+)
+
+type Selection struct {
+	Project *project.Project // nil only for ActionNew
+	Action  Action
+	Query   string
+}
+
+// fzf keys mapped to actions, enter is the default open action
+var actionKeys = []struct {
+	Key    string
+	Action Action
+}{
+	{"ctrl-x", ActionKill},
+	{"ctrl-e", ActionEdit},
+	{"ctrl-d", ActionDelete},
+	{"ctrl-n", ActionNew},
 }
 
 const (
@@ -28,9 +72,15 @@ type projectEntry struct {
 	Project     *project.Project
 	DisplayName string
 	Prefix      string
+	Suffix      string
 	Order       int
 }
 
+// line is the entry as displayed to the user
+func (e projectEntry) line() string {
+	return e.Prefix + e.DisplayName + e.Suffix
+}
+
 func entryFromProject(p *project.Project) (projectEntry, error) {
 	if p == nil {
 		return projectEntry{}, ErrUnexpectedState.WithMsg("project is nil")
@@ -38,10 +88,11 @@ func entryFromProject(p *project.Project) (projectEntry, error) {
 
 	switch p.Type {
 	case project.TypeTmuxSession:
+		// session without a template
 		return projectEntry{
 			Project:     p,
 			DisplayName: string(p.Name),
-			Prefix:      "(Active) ",
+			Prefix:      "(Session) ",
 			Order:       0, // order active sessions first
 		}, nil
 
@@ -56,53 +107,187 @@ func entryFromProject(p *project.Project) (projectEntry, error) {
 			return projectEntry{}, ErrUnexpectedState.WithMsg("project name cannot be empty")
 		}
 
+		// templates from shared sources are labeled with the source name
+		var suffix string
+		if p.Source != "" {
+			suffix = fmt.Sprintf(" [%s]", p.Source)
+		}
+
+		if p.Running {
+			return projectEntry{
+				Project:     p,
+				DisplayName: displayName,
+				Prefix:      "(Active) ",
+				Suffix:      suffix,
+				Order:       0,
+			}, nil
+		}
+
 		return projectEntry{
 			Project:     p,
 			DisplayName: displayName,
+			Suffix:      suffix,
 			Order:       1,
 		}, nil
 
+	case project.TypeDirectory:
+		return projectEntry{
+			Project:     p,
+			DisplayName: shortenHome(string(p.Template.Root)),
+			Prefix:      "(Dir) ",
+			Order:       -1, // discovered directories are only a fallback for missing templates
+		}, nil
+
 	default:
 		return projectEntry{}, ErrUnexpectedState.WithMsg("unhandled project type")
 	}
 }
 
 func (s *FzfProjectSelector) SelectFrom(items []project.Project, prompt string) (*project.Project, error) {
-	var itemsInternal []projectEntry
-	for _, item := range items {
-		entry, err := entryFromProject(&item)
-		if err != nil {
-			return nil, err
-		}
-		itemsInternal = append(itemsInternal, entry)
+	nameMap, input, err := s.prepareInput(items)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("fzf")
+	cmd.Stdin = &input
+	cmd.Args = append(cmd.Args, "--prompt", prompt)
+
+	lines, err := s.run(cmd)
+	if err != nil {
+		return nil, err
 	}
 
-	slices.SortFunc(itemsInternal, func(a, b projectEntry) int {
-		if a.Order != b.Order {
-			// sort ascending by order first
-			return b.Order - a.Order
+	selected, ok := nameMap[lines[0]]
+	if !ok {
+		return nil, ErrUnexpectedState.WithMsg("selected project not found")
+	}
+
+	return selected, nil
+}
+
+func (s *FzfProjectSelector) SelectMany(items []project.Project, prompt string) ([]project.Project, error) {
+	nameMap, input, err := s.prepareInput(items)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("fzf", "--multi")
+	cmd.Stdin = &input
+	cmd.Args = append(cmd.Args, "--prompt", prompt)
+
+	lines, err := s.run(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []project.Project
+	for _, line := range lines {
+		p, ok := nameMap[line]
+		if !ok {
+			return nil, ErrUnexpectedState.WithMsg("selected project not found")
 		}
+		selected = append(selected, *p)
+	}
 
-		aName := strings.ToLower(a.DisplayName)
-		bName := strings.ToLower(b.DisplayName)
+	return selected, nil
+}
 
-		// sort case-insensitive ascending
-		return strings.Compare(bName, aName)
-	})
+func (s *FzfProjectSelector) SelectAction(load func() ([]project.Project, error), prompt string) (Selection, error) {
+	items, err := load()
+	if err != nil {
+		return Selection{}, err
+	}
 
-	nameMap := make(map[string]*project.Project)
 	var input bytes.Buffer
+	if err := s.WriteEntries(&input, items); err != nil {
+		return Selection{}, err
+	}
 
-	for _, item := range itemsInternal {
-		fullName := item.Prefix + item.DisplayName
-		nameMap[fullName] = item.Project
-		input.WriteString(fullName + "\n")
+	var keys, help []string
+	for _, k := range actionKeys {
+		keys = append(keys, k.Key)
+		help = append(help, fmt.Sprintf("%s: %s", k.Key, k.Action))
 	}
 
 	cmd := exec.Command("fzf")
 	cmd.Stdin = &input
 	cmd.Args = append(cmd.Args, "--prompt", prompt)
+	cmd.Args = append(cmd.Args, "--print-query", "--expect", strings.Join(keys, ","))
+	// first field is the entry key, which is hidden from the user
+	cmd.Args = append(cmd.Args, "--delimiter", "\t", "--with-nth", "2..")
+
+	if s.ReloadCommand != "" {
+		cmd.Args = append(cmd.Args, "--bind", fmt.Sprintf("ctrl-r:reload(%s)", s.ReloadCommand))
+		help = append(help, "ctrl-r: reload")
+	}
+	cmd.Args = append(cmd.Args, "--header", strings.Join(help, ", "))
 
+	output, exitCode, err := s.E.Execute(cmd)
+	switch {
+	case exitCode == 130:
+		return Selection{}, ErrSelectorCancelled.WithMsg("operation cancelled")
+	case exitCode == 1:
+		// no match, still valid when creating a new project from the query
+	case err != nil:
+		return Selection{}, ErrSelectorFailed.WithMsg(err.Error())
+	}
+
+	// output lines are: query, pressed key, selected entry (missing if nothing matched)
+	lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+	if len(lines) < 2 {
+		return Selection{}, ErrUnexpectedState.WithMsg("unexpected selector output")
+	}
+
+	selection := Selection{Action: ActionOpen, Query: lines[0]}
+	for _, k := range actionKeys {
+		if k.Key == lines[1] {
+			selection.Action = k.Action
+		}
+	}
+
+	if selection.Action == ActionNew {
+		return selection, nil
+	}
+
+	if len(lines) < 3 {
+		return Selection{}, ErrUnexpectedState.WithMsg("nothing was selected")
+	}
+
+	key, _, _ := strings.Cut(lines[2], "\t")
+	selected, err := findByKey(items, key)
+	if ErrUnexpectedState.Equal(err) {
+		// list might have been reloaded in the meantime, so try with fresh items
+		if items, err = load(); err != nil {
+			return Selection{}, err
+		}
+		selected, err = findByKey(items, key)
+	}
+	if err != nil {
+		return Selection{}, err
+	}
+
+	selection.Project = selected
+	return selection, nil
+}
+
+func (s *FzfProjectSelector) WriteEntries(w io.Writer, items []project.Project) error {
+	entries, err := s.sortedEntries(items)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", entryKey(entry.Project), entry.line()); err != nil {
+			return ErrSelectorFailed.WithMsg(err.Error())
+		}
+	}
+
+	return nil
+}
+
+// runs fzf and returns selected lines, fzf prints one selection per line
+func (s *FzfProjectSelector) run(cmd *exec.Cmd) ([]string, error) {
 	output, exitCode, err := s.E.Execute(cmd)
 	if exitCode == 130 {
 		return nil, ErrSelectorCancelled.WithMsg("operation cancelled")
@@ -110,11 +295,144 @@ func (s *FzfProjectSelector) SelectFrom(items []project.Project, prompt string)
 		return nil, ErrSelectorFailed.WithMsg(err.Error())
 	}
 
-	output = output[:len(output)-1] // trim newline character
-	selected, ok := nameMap[output]
-	if !ok {
-		return nil, ErrUnexpectedState.WithMsg("selected project not found")
+	output = strings.TrimSuffix(output, "\n") // trim trailing newline character
+	if output == "" {
+		return nil, ErrUnexpectedState.WithMsg("nothing was selected")
 	}
 
-	return selected, nil
+	return strings.Split(output, "\n"), nil
+}
+
+// sorts the items and builds fzf input together with a lookup map of displayed lines
+func (s *FzfProjectSelector) prepareInput(items []project.Project) (map[string]*project.Project, bytes.Buffer, error) {
+	var input bytes.Buffer
+
+	entries, err := s.sortedEntries(items)
+	if err != nil {
+		return nil, input, err
+	}
+
+	nameMap := make(map[string]*project.Project)
+
+	for _, item := range entries {
+		fullName := item.line()
+		nameMap[fullName] = item.Project
+		input.WriteString(fullName + "\n")
+	}
+
+	return nameMap, input, nil
+}
+
+func (s *FzfProjectSelector) sortedEntries(items []project.Project) ([]projectEntry, error) {
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+
+	var itemsInternal []projectEntry
+	for _, item := range items {
+		entry, err := entryFromProject(&item)
+		if err != nil {
+			return nil, err
+		}
+		entry.Suffix += sessionDetails(item.Session, now)
+		if item.Broken != "" {
+			entry.Suffix += " (broken: " + item.Broken + ")"
+		}
+		itemsInternal = append(itemsInternal, entry)
+	}
+
+	var order Ordering = AlphabeticalOrder{}
+	if s.Order != nil {
+		order = s.Order
+	}
+
+	if err := order.sort(itemsInternal); err != nil {
+		return nil, err
+	}
+
+	// whatever the ordering, directories are fed last, so they end up farthest from the prompt
+	slices.SortStableFunc(itemsInternal, func(a, b projectEntry) int {
+		return isDirectory(a) - isDirectory(b)
+	})
+
+	return itemsInternal, nil
+}
+
+// running sessions show whether someone is attached to them or for how long they are idle,
+// so forgotten sessions stand out, and whether their template changed since they were built
+func sessionDetails(session *project.Session, now time.Time) string {
+	if session == nil {
+		return ""
+	}
+
+	windows := fmt.Sprintf("%d windows", session.Windows)
+	if session.Windows == 1 {
+		windows = "1 window"
+	}
+
+	details := []string{windows}
+	if session.Attached > 0 {
+		details = append(details, "attached")
+	} else {
+		details = append(details, "idle "+session.Idle(now))
+	}
+	if session.Stale {
+		details = append(details, "stale")
+	}
+	if session.Socket != "" {
+		details = append(details, "on "+session.Socket)
+	}
+
+	return " (" + strings.Join(details, ", ") + ")"
+}
+
+// stable identifier of a project that survives reloading the list
+func entryKey(p *project.Project) string {
+	if p.Type == project.TypeTmuxSession {
+		if p.Session != nil && p.Session.Socket != "" {
+			// sessions of other servers may share the name with sessions of the default one
+			return "session:" + string(p.Name) + "@" + p.Session.Socket
+		}
+		return "session:" + string(p.Name)
+	}
+	if p.Type == project.TypeDirectory {
+		return "dir:" + string(p.Template.Root)
+	}
+	if p.Source != "" {
+		// shared sources may contain copies of personal templates
+		return "template:" + string(p.Source) + "/" + string(p.UUID)
+	}
+	return "template:" + string(p.UUID)
+}
+
+// directories are usually under home, so spare the width of the selector
+func shortenHome(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~/" + rest
+	}
+	return path
+}
+
+func findByKey(items []project.Project, key string) (*project.Project, error) {
+	for _, item := range items {
+		if entryKey(&item) == key {
+			return &item, nil
+		}
+	}
+	return nil, ErrUnexpectedState.WithMsg("selected project not found")
+}
+
+func isDirectory(e projectEntry) int {
+	if e.Project.Type == project.TypeDirectory {
+		return 1
+	}
+	return 0
 }
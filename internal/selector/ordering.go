@@ -0,0 +1,106 @@
+package selector
+
+import (
+	"slices"
+	"strings"
+	"thop/internal/config"
+	"thop/internal/history"
+	"time"
+)
+
+// Ordering decides the order in which entries are fed to the selector,
+// first entry is placed closest to the prompt
+type Ordering interface {
+	sort(entries []projectEntry) error
+}
+
+// AlphabeticalOrder lists active sessions first, then everything else alphabetically
+type AlphabeticalOrder struct{}
+
+// FrecencyOrder lists most frequently and recently used projects first,
+// with the previously used one pinned second for quick toggling
+type FrecencyOrder struct {
+	History history.History
+	Now     func() time.Time
+}
+
+// NewOrdering returns the ordering strategy chosen in config
+func NewOrdering(order config.Order, h history.History) Ordering {
+	if order == config.OrderFrecency {
+		return FrecencyOrder{History: h}
+	}
+	return AlphabeticalOrder{}
+}
+
+func (AlphabeticalOrder) sort(entries []projectEntry) error {
+	slices.SortFunc(entries, func(a, b projectEntry) int {
+		if a.Order != b.Order {
+			// sort ascending by order first
+			return b.Order - a.Order
+		}
+
+		aName := strings.ToLower(a.DisplayName)
+		bName := strings.ToLower(b.DisplayName)
+
+		// sort case-insensitive ascending
+		return strings.Compare(bName, aName)
+	})
+
+	return nil
+}
+
+func (o FrecencyOrder) sort(entries []projectEntry) error {
+	// projects without history keep alphabetical order
+	if err := (AlphabeticalOrder{}).sort(entries); err != nil {
+		return err
+	}
+
+	records, err := o.History.Entries()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if o.Now != nil {
+		now = o.Now()
+	}
+
+	score := func(e projectEntry) float64 {
+		for _, record := range records {
+			if record.Matches(*e.Project) {
+				return record.Frecency(now)
+			}
+		}
+		return 0
+	}
+
+	slices.SortStableFunc(entries, func(a, b projectEntry) int {
+		// descending by score
+		aScore, bScore := score(a), score(b)
+		switch {
+		case aScore > bScore:
+			return -1
+		case aScore < bScore:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	// records are ordered by last usage, so the second one is the previous project
+	if len(records) < 2 {
+		return nil
+	}
+
+	previous := slices.IndexFunc(entries, func(e projectEntry) bool {
+		return records[1].Matches(*e.Project)
+	})
+
+	if previous > 1 {
+		pinned := entries[previous]
+		copy(entries[2:previous+1], entries[1:previous])
+		entries[1] = pinned
+	}
+
+	return nil
+}
@@ -1,33 +1,65 @@
 package service
 
 import (
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"thop/internal/bundle"
 	"thop/internal/config"
+	"thop/internal/discovery"
 	"thop/internal/executor"
+	"thop/internal/fsystem"
+	"thop/internal/git"
+	"thop/internal/history"
 	"thop/internal/multiplexer"
 	"thop/internal/problem"
+	"thop/internal/prompt"
 	"thop/internal/selector"
 	"thop/internal/storage"
 	"thop/internal/types"
 	"thop/internal/types/project"
 	"thop/internal/types/template"
 	"thop/internal/types/window"
+	"time"
 )
 
 type Service interface {
 	CreateProject(template.Root, project.Name) error
-	OpenProject(project.Name) error
+	OpenProject(project.Name, OpenOptions) error
+	OpenProjects([]project.Name) error
+	OpenLast() error
 	DeleteProject(project.Name) error
+	DeleteProjects([]project.Name) error
 	EditProject(project.Name) error
-	KillSession(project.Name) error
+	KillSession(project.Name, KillOptions) error
+	KillSessions([]project.Name, KillOptions) error
+	ReloadProject(project.Name, ReloadOptions) error
+	WriteSelectorEntries(io.Writer) error
+	ListProjects(ListFilter) ([]ListItem, error)
+	ShowProject(project.Name) (ProjectPlan, error)
+	ExportScript(project.Name) (ProjectPlan, error)
+	ImportProjects(string, ImportOptions) error
+	ExportProjects([]project.Name, bool) (bundle.Bundle, error)
 }
 
 type AppService struct {
 	Selector    selector.ProjectSelector
 	Multiplexer multiplexer.Multiplexer
 	Storage     storage.Storage
+	History     history.History
 	Config      *config.Config
-	E           executor.CommandExecutor
+	FileSystem  fsystem.FileSystem
+	Prompter    prompt.Prompter
+	// Discoverer finds directories offered next to templates, discovery is disabled when nil
+	Discoverer discovery.Discoverer
+	Git        git.GitClient
+	E          executor.CommandExecutor
+	// Now tells how long sessions are idle, defaults to time.Now
+	Now func() time.Time
 }
 
 const (
@@ -37,12 +69,72 @@ const (
 	ErrSelectedNonExisting      problem.Key = "THOP_SELECTED_NON_EXISTING"
 	ErrSessionNotFound          problem.Key = "THOP_SESSION_NOT_FOUND"
 	ErrProjectOrSessionNotFound problem.Key = "THOP_PROJECT_OR_SESSION_NOT_FOUND"
+	ErrBatchFailed              problem.Key = "THOP_BATCH_FAILED"
+	ErrNotATemplate             problem.Key = "THOP_NOT_A_TEMPLATE"
+	ErrNotASession              problem.Key = "THOP_NOT_A_SESSION"
+	ErrNoPreviousSession        problem.Key = "THOP_NO_PREVIOUS_SESSION"
+	ErrReadOnlyTemplate         problem.Key = "THOP_READ_ONLY_TEMPLATE"
 )
 
 const (
 	TemplateVersion = types.V1
 )
 
+// ListFilter narrows down listed projects, kinds (templates, sessions) are combined together
+// and when none are set everything is listed
+type ListFilter struct {
+	Templates bool // projects with a template
+	Sessions  bool // sessions without a template
+	Running   bool // projects with a running session
+}
+
+// OpenOptions tweak how a project is opened
+type OpenOptions struct {
+	// Path is a directory to open with the default template, instead of a project
+	Path string
+	Save bool // persist the directory as a project before opening it
+	// Worktree is a branch to open the project in, checked out into a new worktree when needed
+	Worktree     string
+	PickWorktree bool // select from existing worktrees of the project
+	// Rebuild recreates the running session from the current version of its template
+	Rebuild bool
+}
+
+// KillOptions tweak how sessions are killed, filters pick sessions in bulk and are combined together
+type KillOptions struct {
+	RemoveWorktree bool // remove worktree of the session when it has no changes
+	All            bool // every running session
+	Others         bool // every session except the one the user is in
+	// Idle picks sessions without activity for at least this long, 0 disables the filter
+	Idle  time.Duration
+	Regex bool // names are regular expressions instead of globs
+	Yes   bool // kill picked sessions without asking for confirmation
+	// SwitchTo is a project or session to switch to when killing the session the user is in
+	SwitchTo project.Name
+}
+
+type ListItem struct {
+	UUID        project.UUID            `json:"uuid"`
+	Name        project.Name            `json:"name"`
+	SessionName multiplexer.SessionName `json:"session_name"`
+	Root        template.Root           `json:"root"`
+	// Windows of the running session, or of the template when it's not running
+	Windows  int            `json:"windows"`
+	Running  bool           `json:"running"`
+	Template bool           `json:"template"`
+	Source   project.Source `json:"source,omitempty"`
+	// Socket of the tmux server the session runs (or would run) on, empty for the default one
+	Socket string `json:"socket,omitempty"`
+	// Error explains why the template can't be opened, empty when it's fine
+	Error string `json:"error,omitempty"`
+	// below fields describe the running session
+	Attached    bool       `json:"attached"`
+	Activity    *time.Time `json:"activity,omitempty"` // last activity in the session
+	SessionPath string     `json:"session_path,omitempty"`
+	Managed     bool       `json:"managed"` // created by thop
+	Stale       bool       `json:"stale"`   // template changed since the session was built
+}
+
 func (s *AppService) CreateProject(root template.Root, name project.Name) error {
 	if name == "" {
 		return ErrEmptyProjectName.WithMsg("project name cannot be empty")
@@ -52,7 +144,13 @@ func (s *AppService) CreateProject(root template.Root, name project.Name) error
 		return ErrEmptyRootPath.WithMsg("root path cannot be empty")
 	}
 
-	p := project.Project{
+	p := defaultProject(root, name)
+	return s.Storage.Save(&p)
+}
+
+// template used for new projects and ad-hoc sessions of discovered directories
+func defaultProject(root template.Root, name project.Name) project.Project {
+	return project.Project{
 		Name:    name,
 		Version: TemplateVersion,
 		Template: template.Template{
@@ -62,16 +160,25 @@ func (s *AppService) CreateProject(root template.Root, name project.Name) error
 			},
 		},
 	}
-
-	return s.Storage.Save(&p)
 }
 
-func (s *AppService) OpenProject(name project.Name) error {
+func (s *AppService) OpenProject(name project.Name, opts OpenOptions) error {
+	if opts.Worktree != "" || opts.PickWorktree {
+		return s.openWorktree(name, opts)
+	}
+
+	if opts.Path != "" {
+		return s.openDirectory(opts.Path, opts)
+	}
+
 	if name != "" {
 		p, err := s.Storage.Find(name)
 
 		if err == nil {
-			return s.Multiplexer.AttachProject(p)
+			if p, err = s.withSession(p); err != nil {
+				return err
+			}
+			return s.openSelected(p, opts)
 		}
 
 		if !storage.ErrProjectNotFound.Equal(err) {
@@ -85,32 +192,519 @@ func (s *AppService) OpenProject(name project.Name) error {
 		}
 
 		for _, session := range active {
-			if session.Name == name {
-				return s.Multiplexer.AttachProject(session)
+			if sessionNamed(session, name) {
+				return s.openSelected(session, opts)
 			}
 		}
 
 		return ErrProjectOrSessionNotFound.WithMsg(name)
 	}
 
+	// selector keeps coming back after actions other than open, until cancelled
+	for {
+		selection, err := s.Selector.SelectAction(s.listSelectable, "Select project to open > ")
+		if err != nil {
+			return err
+		}
+
+		if selection.Action == selector.ActionOpen {
+			return s.openSelected(*selection.Project, opts)
+		}
+
+		if err := s.runAction(selection); err != nil {
+			if !isRecoverable(err) {
+				return err
+			}
+			// picking a wrong entry for the action is a slip, not a reason to leave the selector
+			fmt.Println(err.Error())
+		}
+	}
+}
+
+// action errors caused by what was picked in the selector, rather than by something failing
+var recoverableActionErrors = []problem.Key{ErrNotATemplate, ErrNotASession, ErrEmptyProjectName, ErrReadOnlyTemplate}
+
+func isRecoverable(err error) bool {
+	return slices.ContainsFunc(recoverableActionErrors, func(key problem.Key) bool { return key.Equal(err) })
+}
+
+func (s *AppService) openSelected(p project.Project, opts OpenOptions) error {
+	if p.Type == project.TypeDirectory {
+		return s.openDirectory(string(p.Template.Root), opts)
+	}
+	if opts.Rebuild {
+		return s.rebuild(p)
+	}
+	return s.attach(p)
+}
+
+// records usage of the project before attaching, since attaching outside of tmux blocks until detached
+func (s *AppService) attach(p project.Project) error {
+	if err := s.History.Record(p); err != nil {
+		// history is not essential, so don't stop the user from opening the project
+		fmt.Println("Failed to record history:", err.Error())
+	}
+
+	resolved, err := s.resolve(p)
+	if err != nil {
+		return err
+	}
+
+	if p.Session != nil && p.Session.Stale {
+		fmt.Println("Template of", p.Name, "changed since the session was built, use open --rebuild to apply the changes")
+	}
+
+	return s.Multiplexer.AttachProject(resolved)
+}
+
+// rebuild recreates the session from the current template, or just builds it when it's not running
+func (s *AppService) rebuild(p project.Project) error {
+	if err := s.History.Record(p); err != nil {
+		fmt.Println("Failed to record history:", err.Error())
+	}
+
+	resolved, err := s.resolve(p)
+	if err != nil {
+		return err
+	}
+
+	return s.Multiplexer.RebuildProject(resolved)
+}
+
+// OpenLast switches to the previously used session, preferring what tmux remembers
+// and falling back to thop history, which also allows rebuilding sessions from templates
+func (s *AppService) OpenLast() error {
 	projects, err := s.Storage.List()
 	if err != nil {
 		return err
 	}
 
-	sessions, err := s.Multiplexer.ListActiveSessions()
+	sessions, err := s.listSessions(projects)
+	if err != nil {
+		return err
+	}
+
+	// templates carry their running sessions, which may run under another name than the template resolves to
+	projects = s.mergeSessions(projects, slices.Clone(sessions))[:len(projects)]
+
+	previous, err := s.Multiplexer.PreviousSession()
+	if err != nil {
+		return err
+	}
+
+	if previous != "" {
+		if p, ok := s.findBySessionName(projects, previous); ok {
+			return s.attach(p)
+		}
+		if p, ok := s.findBySessionName(sessions, previous); ok {
+			return s.attach(p)
+		}
+	}
+
+	current, err := s.Multiplexer.CurrentSession()
+	if err != nil {
+		return err
+	}
+
+	entries, err := s.History.Entries()
 	if err != nil {
 		return err
 	}
 
-	projects = append(projects, sessions...)
+	for _, entry := range entries {
+		candidates := sessions
+		if entry.UUID != "" {
+			// templates can be rebuilt, so they don't need a running session
+			candidates = projects
+		}
+
+		i := slices.IndexFunc(candidates, func(p project.Project) bool { return entry.Matches(p) })
+		if i == -1 {
+			continue
+		}
+
+		if name, err := s.Multiplexer.ResolveSessionName(candidates[i]); err == nil && name == current {
+			continue
+		}
+
+		return s.attach(candidates[i])
+	}
+
+	return ErrNoPreviousSession.WithMsg("no previous session to go back to")
+}
+
+func (s *AppService) findBySessionName(projects []project.Project, name multiplexer.SessionName) (project.Project, bool) {
+	for _, p := range projects {
+		if resolved, err := s.Multiplexer.ResolveSessionName(p); err == nil && resolved == name {
+			return p, true
+		}
+	}
+	return project.Project{}, false
+}
+
+func (s *AppService) runAction(selection selector.Selection) error {
+	switch selection.Action {
+	case selector.ActionNew:
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		return s.CreateProject(template.Root(cwd), project.Name(selection.Query))
+
+	case selector.ActionKill:
+		if selection.Project.Type == project.TypeDirectory {
+			// session name derived from the directory may belong to an unrelated session
+			return ErrNotASession.WithMsg(selection.Project.Name, " is a directory, not a session")
+		}
+		if selection.Project.Type != project.TypeTmuxSession && !selection.Project.Running {
+			fmt.Println("Session", selection.Project.Name, "is not running")
+			return nil
+		}
+		return s.Multiplexer.KillSession(*selection.Project)
+
+	case selector.ActionEdit:
+		if selection.Project.Type != project.TypeTemplate {
+			return ErrNotATemplate.WithMsg(selection.Project.Name, " is not a template")
+		}
+		return s.edit(*selection.Project)
+
+	case selector.ActionDelete:
+		if selection.Project.Type != project.TypeTemplate {
+			return ErrNotATemplate.WithMsg(selection.Project.Name, " is not a template")
+		}
+		// ctrl-d is also a common way to leave fzf, so don't take it as a decision to delete
+		confirmed, err := s.Prompter.Confirm(fmt.Sprintf("Delete template %s?", selection.Project.Name))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Kept", selection.Project.Name)
+			return nil
+		}
+		return s.delete(*selection.Project)
+
+	default:
+		return selector.ErrUnexpectedState.WithMsg("unhandled selector action ", selection.Action)
+	}
+}
+
+// WriteSelectorEntries writes the open list in selector format, used to reload it from inside the selector
+func (s *AppService) WriteSelectorEntries(w io.Writer) error {
+	projects, err := s.listSelectable()
+	if err != nil {
+		return err
+	}
+
+	return s.Selector.WriteEntries(w, projects)
+}
+
+// ProjectPlan is a fully resolved project together with commands used to build its session
+type ProjectPlan struct {
+	Project     project.Project         `json:"project"`
+	SessionName multiplexer.SessionName `json:"session_name"`
+	Commands    [][]string              `json:"commands"`
+}
+
+// ShowProject resolves the template the same way as when opening it, without building the session
+func (s *AppService) ShowProject(name project.Name) (ProjectPlan, error) {
+	p, err := s.findOrSelect(name, "Select project to show > ")
+	if err != nil {
+		return ProjectPlan{}, err
+	}
+
+	resolved, err := s.resolve(p)
+	if err != nil {
+		return ProjectPlan{}, err
+	}
+
+	return s.plan(resolved)
+}
+
+// ExportScript plans the session like ShowProject, but leaves ~ and environment variables in roots
+// as they are, so they are expanded on the machine the exported script runs on
+func (s *AppService) ExportScript(name project.Name) (ProjectPlan, error) {
+	p, err := s.findOrSelect(name, "Select project to export > ")
+	if err != nil {
+		return ProjectPlan{}, err
+	}
+
+	inherited, err := s.inherit(p)
+	if err != nil {
+		return ProjectPlan{}, err
+	}
+
+	return s.plan(inherited)
+}
+
+func (s *AppService) plan(p project.Project) (ProjectPlan, error) {
+	sessionName, err := s.Multiplexer.ResolveSessionName(p)
+	if err != nil {
+		return ProjectPlan{}, err
+	}
+
+	commands, err := s.Multiplexer.Plan(p)
+	if err != nil {
+		return ProjectPlan{}, err
+	}
+
+	return ProjectPlan{Project: p, SessionName: sessionName, Commands: commands}, nil
+}
+
+// ExportProjects bundles templates as they are stored, without resolving them,
+// all exports every template, otherwise selector is used when no names are given
+func (s *AppService) ExportProjects(names []project.Name, all bool) (bundle.Bundle, error) {
+	projects, err := s.Storage.List()
+	if err != nil {
+		return bundle.Bundle{}, err
+	}
+
+	if all {
+		return bundle.New(projects), nil
+	}
+
+	selected, failures, err := s.findOrSelectMany(projects, names, "Select projects to export > ")
+	if err != nil {
+		return bundle.Bundle{}, err
+	}
+
+	if err := batchError(failures, len(selected)+len(failures)); err != nil {
+		return bundle.Bundle{}, err
+	}
+
+	return bundle.New(selected), nil
+}
+
+// ListProjects lists templates and sessions in non-interactive way, ordered by name
+func (s *AppService) ListProjects(filter ListFilter) ([]ListItem, error) {
+	projects, err := s.listOpenable()
+	if err != nil {
+		return nil, err
+	}
+
+	anyKind := !filter.Templates && !filter.Sessions
+
+	var items []ListItem
+	for _, p := range projects {
+		isTemplate := p.Type == project.TypeTemplate
+		running := p.Running || !isTemplate
+
+		if !anyKind && !(filter.Templates && isTemplate) && !(filter.Sessions && !isTemplate) {
+			continue
+		}
+
+		if filter.Running && !running {
+			continue
+		}
+
+		sessionName, err := s.Multiplexer.ResolveSessionName(p)
+		if err != nil {
+			return nil, err
+		}
+
+		item := ListItem{
+			UUID:        p.UUID,
+			Name:        p.Name,
+			SessionName: sessionName,
+			Root:        p.Template.Root,
+			Windows:     len(p.Template.Windows),
+			Running:     running,
+			Template:    isTemplate,
+			Source:      p.Source,
+			Socket:      s.socketOf(p, projects),
+			Error:       p.Broken,
+		}
+
+		if p.Session != nil {
+			item.Windows = p.Session.Windows
+			item.Attached = p.Session.Attached > 0
+			item.Activity = &p.Session.Activity
+			item.SessionPath = p.Session.Path
+			item.Managed = p.Session.Managed
+			item.Stale = p.Session.Stale
+			item.Socket = p.Session.Socket
+		}
+
+		items = append(items, item)
+	}
+
+	slices.SortStableFunc(items, func(a, b ListItem) int {
+		return strings.Compare(strings.ToLower(string(a.Name)), strings.ToLower(string(b.Name)))
+	})
 
-	found, err := s.Selector.SelectFrom(projects, "Select project to open > ")
+	return items, nil
+}
+
+// templates together with active sessions, sessions built from a template are merged into it
+func (s *AppService) listOpenable() ([]project.Project, error) {
+	projects, err := s.Storage.List()
+	if err != nil {
+		return nil, err
+	}
+
+	s.flagBroken(projects)
+
+	sessions, err := s.listSessions(projects)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.mergeSessions(projects, sessions), nil
+}
+
+// openable projects followed by discovered directories, directories already used
+// as a template root are left out since the template is a better way to open them
+func (s *AppService) listSelectable() ([]project.Project, error) {
+	projects, err := s.listOpenable()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Discoverer == nil {
+		return projects, nil
+	}
+
+	dirs, err := s.Discoverer.Discover()
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []string
+	for _, p := range projects {
+		if p.Type != project.TypeTemplate {
+			continue
+		}
+		if root, err := expandPath(string(p.Template.Root)); err == nil {
+			roots = append(roots, filepath.Clean(root))
+		}
+	}
+
+	for _, dir := range dirs {
+		if slices.Contains(roots, filepath.Clean(dir)) {
+			continue
+		}
+
+		projects = append(projects, project.Project{
+			Name:     project.Name(filepath.Base(dir)),
+			Type:     project.TypeDirectory,
+			Template: template.Template{Root: template.Root(dir)},
+		})
+	}
+
+	return projects, nil
+}
+
+// reconciles templates with sessions by the template UUID the session was tagged with,
+// or by resolved session name, sessions left without a matching template are kept as they are
+func (s *AppService) mergeSessions(projects []project.Project, sessions []project.Project) []project.Project {
+	orphans := slices.Clone(sessions)
+
+	for i := range projects {
+		if len(orphans) == 0 {
+			break
+		}
+
+		name, err := s.Multiplexer.ResolveSessionName(projects[i])
+		if err != nil {
+			continue
+		}
+
+		socket := s.socketOf(projects[i], projects)
+		j := slices.IndexFunc(orphans, func(session project.Project) bool {
+			// sessions of the same name on different servers are different sessions
+			if session.Session != nil && session.Session.Socket != socket {
+				return false
+			}
+			if session.Session != nil && session.Session.UUID != "" {
+				return session.Session.UUID == projects[i].UUID
+			}
+			return multiplexer.SanitizeSessionName(string(session.Name)) == name
+		})
+		if j == -1 {
+			continue
+		}
+
+		projects[i].Running = true
+		projects[i].Session = s.checkStale(projects[i], orphans[j].Session, projects)
+		orphans = slices.Delete(orphans, j, j+1)
+	}
+
+	return append(projects, orphans...)
+}
+
+// withSession matches the template with its running session, the session may run under another name
+// than the template resolves to, e.g. when it was renamed, so it's matched the same way as in listings
+func (s *AppService) withSession(p project.Project) (project.Project, error) {
+	if p.Type != project.TypeTemplate || p.Session != nil {
+		return p, nil
+	}
+
+	projects, err := s.listOpenable()
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	i := slices.IndexFunc(projects, func(other project.Project) bool {
+		return other.Type == project.TypeTemplate && other.UUID == p.UUID && other.Source == p.Source
+	})
+	if i != -1 {
+		p.Running = projects[i].Running
+		p.Session = projects[i].Session
+	}
+
+	return p, nil
+}
+
+// compares hash the session was tagged with to the current template, sessions built before
+// thop started tagging them can't be told apart, so they are never stale
+func (s *AppService) checkStale(p project.Project, session *project.Session, projects []project.Project) *project.Session {
+	if session == nil || session.Hash == "" {
+		return session
+	}
+
+	resolved, err := s.resolveFrom(p, projects)
+	if err != nil || resolved.Template.Hash() == session.Hash {
+		return session
+	}
+
+	stale := *session
+	stale.Stale = true
+	return &stale
+}
+
+// OpenProjects starts sessions for all given (or selected) projects and attaches to the first one
+func (s *AppService) OpenProjects(names []project.Name) error {
+	projects, err := s.listOpenable()
 	if err != nil {
 		return err
 	}
 
-	return s.Multiplexer.AttachProject(*found)
+	selected, failures, err := s.findOrSelectMany(projects, names, "Select projects to open > ")
+	if err != nil {
+		return err
+	}
+
+	total := len(selected) + len(failures)
+
+	var started []project.Project
+	failures = append(failures, runBatch(selected, func(p project.Project) error {
+		resolved, err := s.resolveFrom(p, projects)
+		if err != nil {
+			return err
+		}
+		if err := s.Multiplexer.StartProject(resolved); err != nil {
+			return err
+		}
+		started = append(started, p)
+		return nil
+	})...)
+
+	if len(started) > 0 {
+		if err := s.attach(started[0]); err != nil {
+			failures = append(failures, batchFailure{Name: started[0].Name, Err: err})
+		}
+	}
+
+	return batchError(failures, total)
 }
 
 func (s *AppService) DeleteProject(name project.Name) error {
@@ -119,15 +713,53 @@ func (s *AppService) DeleteProject(name project.Name) error {
 		return err
 	}
 
+	return s.delete(p)
+}
+
+func (s *AppService) delete(p project.Project) error {
+	if p.Source != "" {
+		return ErrReadOnlyTemplate.WithMsg(p.Name, " comes from read-only source ", p.Source, " and can't be deleted")
+	}
+
 	return s.Storage.Delete(p.UUID)
 }
 
+// DeleteProjects deletes all given (or selected) projects, failures are reported together at the end
+func (s *AppService) DeleteProjects(names []project.Name) error {
+	projects, err := s.Storage.List()
+	if err != nil {
+		return err
+	}
+
+	selected, failures, err := s.findOrSelectMany(projects, names, "Select projects to delete > ")
+	if err != nil {
+		return err
+	}
+
+	total := len(selected) + len(failures)
+	failures = append(failures, runBatch(selected, s.delete)...)
+
+	return batchError(failures, total)
+}
+
 func (s *AppService) EditProject(name project.Name) error {
 	p, err := s.findOrSelect(name, "Select project to edit > ")
 	if err != nil {
 		return err
 	}
 
+	return s.edit(p)
+}
+
+func (s *AppService) edit(p project.Project) error {
+	if p.Source != "" {
+		forked, err := s.fork(p)
+		if err != nil || forked == nil {
+			return err
+		}
+		p = *forked
+	}
+
 	templatePath, err := s.Storage.PrepareTemplateFile(p)
 	if err != nil {
 		return err
@@ -143,7 +775,35 @@ func (s *AppService) EditProject(name project.Name) error {
 	return err
 }
 
-func (s *AppService) KillSession(name project.Name) error {
+// fork copies shared template into personal storage once confirmed, the copy takes precedence
+// over the shared one from then on, nil is returned when user declines
+func (s *AppService) fork(p project.Project) (*project.Project, error) {
+	question := fmt.Sprintf("%s comes from read-only source %s, fork it into personal templates?", p.Name, p.Source)
+	confirmed, err := s.Prompter.Confirm(question)
+	if err != nil {
+		return nil, err
+	}
+
+	if !confirmed {
+		fmt.Println("Left", p.Name, "untouched")
+		return nil, nil
+	}
+
+	p.UUID = ""
+	p.Source = ""
+	if err := s.Storage.Save(&p); err != nil {
+		return nil, err
+	}
+
+	fmt.Println("Forked", p.Name, "into personal templates")
+	return &p, nil
+}
+
+func (s *AppService) KillSession(name project.Name, opts KillOptions) error {
+	if opts.picksMany([]project.Name{name}) {
+		return s.killMatching([]project.Name{name}, opts)
+	}
+
 	sessions, err := s.Multiplexer.ListActiveSessions()
 	if err != nil {
 		return err
@@ -151,8 +811,8 @@ func (s *AppService) KillSession(name project.Name) error {
 
 	if name != "" {
 		for _, session := range sessions {
-			if session.Name == name {
-				return s.Multiplexer.KillSession(session)
+			if sessionNamed(session, name) {
+				return s.kill(session, opts)
 			}
 		}
 		return ErrSessionNotFound.WithMsg(name)
@@ -163,7 +823,47 @@ func (s *AppService) KillSession(name project.Name) error {
 		return err
 	}
 
-	return s.Multiplexer.KillSession(*selected)
+	return s.kill(*selected, opts)
+}
+
+// KillSessions kills all given (or selected) sessions, failures are reported together at the end
+func (s *AppService) KillSessions(names []project.Name, opts KillOptions) error {
+	if opts.picksMany(names) {
+		return s.killMatching(names, opts)
+	}
+
+	sessions, err := s.Multiplexer.ListActiveSessions()
+	if err != nil {
+		return err
+	}
+
+	selected, failures, err := s.findOrSelectMany(sessions, names, "Select sessions to kill > ")
+	if err != nil {
+		return err
+	}
+
+	total := len(selected) + len(failures)
+	failures = append(failures, runBatch(selected, func(p project.Project) error { return s.kill(p, opts) })...)
+
+	return batchError(failures, total)
+}
+
+func (s *AppService) kill(session project.Project, opts KillOptions) error {
+	if opts.SwitchTo != "" {
+		if err := s.switchAway(session, opts.SwitchTo); err != nil {
+			return err
+		}
+	}
+
+	if err := s.Multiplexer.KillSession(session); err != nil {
+		return err
+	}
+
+	if opts.RemoveWorktree {
+		return s.removeWorktree(session)
+	}
+
+	return nil
 }
 
 // common logic used by most commands
@@ -185,3 +885,66 @@ func (s *AppService) findOrSelect(name project.Name, prompt string) (project.Pro
 
 	return *selected, nil
 }
+
+// common logic used by batch commands, projects not matching any of the names are reported as failures
+func (s *AppService) findOrSelectMany(projects []project.Project, names []project.Name, prompt string) ([]project.Project, []batchFailure, error) {
+	if len(names) == 0 {
+		selected, err := s.Selector.SelectMany(projects, prompt)
+		return selected, nil, err
+	}
+
+	var selected []project.Project
+	var failures []batchFailure
+
+	for _, name := range names {
+		i := slices.IndexFunc(projects, func(p project.Project) bool {
+			return p.Name == name || (p.Type == project.TypeTmuxSession && sessionNamed(p, name))
+		})
+		if i == -1 {
+			failures = append(failures, batchFailure{Name: name, Err: ErrProjectOrSessionNotFound.WithMsg(name)})
+			continue
+		}
+		selected = append(selected, projects[i])
+	}
+
+	return selected, failures, nil
+}
+
+// sessions can be looked up by the name they were created for, by its sanitized form,
+// as well as by the name they have in tmux, which differs from both once they are renamed
+func sessionNamed(session project.Project, name project.Name) bool {
+	if session.Session != nil && session.Session.Name != "" && session.Session.Name == string(name) {
+		return true
+	}
+	return multiplexer.SanitizeSessionName(string(session.Name)) == multiplexer.SanitizeSessionName(string(name))
+}
+
+type batchFailure struct {
+	Name project.Name
+	Err  error
+}
+
+// runs the action for every project without stopping on failures
+func runBatch(projects []project.Project, action func(project.Project) error) []batchFailure {
+	var failures []batchFailure
+	for _, p := range projects {
+		if err := action(p); err != nil {
+			failures = append(failures, batchFailure{Name: p.Name, Err: err})
+		}
+	}
+	return failures
+}
+
+func batchError(failures []batchFailure, total int) error {
+	if len(failures) == 0 {
+		return nil
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "%d of %d failed", len(failures), total)
+	for _, f := range failures {
+		fmt.Fprintf(&msg, "\n  %s: %s", f.Name, f.Err.Error())
+	}
+
+	return ErrBatchFailed.WithMsg(msg.String())
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"thop/internal/multiplexer"
+	"thop/internal/problem"
+	"thop/internal/types/project"
+	"thop/internal/types/template"
+)
+
+const (
+	ErrDirectoryNotFound problem.Key = "THOP_DIRECTORY_NOT_FOUND"
+)
+
+// opens a session for the directory from the default template without saving it, unless asked to,
+// a template already rooted in the directory is opened instead
+func (s *AppService) openDirectory(path string, opts OpenOptions) error {
+	expanded, err := expandPath(path)
+	if err != nil {
+		return err
+	}
+
+	dir, err := filepath.Abs(expanded)
+	if err != nil {
+		return ErrDirectoryNotFound.WithMsg(err.Error())
+	}
+
+	if _, err := s.FileSystem.ReadDir(dir); err != nil {
+		return ErrDirectoryNotFound.WithMsg(dir, " is not a readable directory")
+	}
+
+	projects, err := s.Storage.List()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range projects {
+		if root, err := expandPath(string(p.Template.Root)); err == nil && filepath.Clean(root) == dir {
+			return s.attach(p)
+		}
+	}
+
+	name, err := s.directorySessionName(dir, projects)
+	if err != nil {
+		return err
+	}
+
+	p := defaultProject(template.Root(dir), name)
+	if opts.Save {
+		if err := s.Storage.Save(&p); err != nil {
+			return err
+		}
+	}
+
+	return s.attach(p)
+}
+
+// names the session after the directory, suffixed when the name is already taken by a template
+// or by a session of another directory, a session of the same directory is reused
+func (s *AppService) directorySessionName(dir string, projects []project.Project) (project.Name, error) {
+	var taken []multiplexer.SessionName
+	for _, p := range projects {
+		if name, err := s.Multiplexer.ResolveSessionName(p); err == nil {
+			taken = append(taken, name)
+		}
+	}
+
+	sessions, err := s.Multiplexer.ListActiveSessions()
+	if err != nil {
+		return "", err
+	}
+
+	base := filepath.Base(dir)
+	for i := 1; ; i++ {
+		name := base
+		if i > 1 {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		sessionName := multiplexer.SanitizeSessionName(name)
+
+		if slices.Contains(taken, sessionName) {
+			continue
+		}
+
+		running := slices.ContainsFunc(sessions, func(session project.Project) bool {
+			return sessionNamed(session, project.Name(name))
+		})
+		if !running {
+			return project.Name(name), nil
+		}
+
+		root, err := s.Multiplexer.SessionRoot(sessionName)
+		if err != nil {
+			return "", err
+		}
+		if filepath.Clean(root) == dir {
+			return project.Name(name), nil
+		}
+	}
+}
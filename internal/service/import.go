@@ -0,0 +1,220 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"thop/internal/bundle"
+	"thop/internal/importer"
+	"thop/internal/problem"
+	"thop/internal/types/project"
+)
+
+const (
+	ErrProjectAlreadyExists    problem.Key = "THOP_PROJECT_ALREADY_EXISTS"
+	ErrFailedToReadImport      problem.Key = "THOP_FAILED_TO_READ_IMPORT"
+	ErrUnknownConflictStrategy problem.Key = "THOP_UNKNOWN_CONFLICT_STRATEGY"
+	ErrUUIDAlreadyUsed         problem.Key = "THOP_UUID_ALREADY_USED"
+	ErrInvalidUUID             problem.Key = "THOP_INVALID_UUID"
+)
+
+// ConflictStrategy decides what happens with imported project, when a project with the same name exists
+type ConflictStrategy string
+
+const (
+	ConflictFail      ConflictStrategy = "" // reported as failure, so nothing gets lost unknowingly
+	ConflictSkip      ConflictStrategy = "skip"
+	ConflictOverwrite ConflictStrategy = "overwrite"
+	ConflictRename    ConflictStrategy = "rename"
+)
+
+type ImportOptions struct {
+	// From is the format of imported files, thop bundle when empty
+	From     importer.Source
+	Roots    []importer.RootMapping
+	Conflict ConflictStrategy
+	// KeepUUID preserves UUIDs from bundles, new ones are generated otherwise
+	KeepUUID bool
+}
+
+// imported is a project waiting to be stored, together with the file it came from
+type imported struct {
+	file        string
+	project     project.Project
+	unsupported []string
+}
+
+// ImportProjects imports thop bundles or configs of other session managers as templates, path is either
+// a single file or a directory of them, projects failing to import don't stop the rest from being imported
+func (s *AppService) ImportProjects(path string, opts ImportOptions) error {
+	if !slices.Contains([]ConflictStrategy{ConflictFail, ConflictSkip, ConflictOverwrite, ConflictRename}, opts.Conflict) {
+		return ErrUnknownConflictStrategy.WithMsg("unknown conflict strategy ", opts.Conflict, ", expected one of: skip, overwrite, rename")
+	}
+
+	existing, err := s.Storage.List()
+	if err != nil {
+		return err
+	}
+
+	files, err := s.importFiles(path)
+	if err != nil {
+		return err
+	}
+
+	var failures []batchFailure
+	total := 0
+
+	for _, file := range files {
+		projects, err := s.readImport(file, opts)
+		if err != nil {
+			failures = append(failures, batchFailure{Name: project.Name(file), Err: err})
+			total++
+			continue
+		}
+
+		for _, i := range projects {
+			total++
+			if err := s.storeImported(i, opts, &existing); err != nil {
+				failures = append(failures, batchFailure{Name: i.project.Name, Err: err})
+			}
+		}
+	}
+
+	return batchError(failures, total)
+}
+
+func (s *AppService) readImport(file string, opts ImportOptions) ([]imported, error) {
+	data, err := s.FileSystem.ReadFile(file)
+	if err != nil {
+		return nil, ErrFailedToReadImport.WithMsg(err.Error())
+	}
+
+	if opts.From == "" {
+		b, err := bundle.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+
+		var projects []imported
+		for _, p := range b.List() {
+			if !opts.KeepUUID {
+				p.UUID = ""
+			}
+			projects = append(projects, imported{file: file, project: importer.MapRoots(p, opts.Roots)})
+		}
+		return projects, nil
+	}
+
+	name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	result, err := importer.Convert(opts.From, project.Name(name), data)
+	if err != nil {
+		return nil, err
+	}
+
+	return []imported{{
+		file:        file,
+		project:     importer.MapRoots(result.Project, opts.Roots),
+		unsupported: result.Unsupported,
+	}}, nil
+}
+
+// stores the project according to the conflict strategy, existing projects are kept
+// up to date, so conflicts between imported projects are handled as well
+func (s *AppService) storeImported(i imported, opts ImportOptions, existing *[]project.Project) error {
+	p := i.project
+
+	if p.UUID != "" {
+		// kept UUID names the template directory, so it must not point anywhere else
+		if !validUUID(p.UUID) {
+			return ErrInvalidUUID.WithMsg("project ", p.Name, " has invalid uuid ", p.UUID)
+		}
+
+		if idx := slices.IndexFunc(*existing, func(e project.Project) bool { return e.UUID == p.UUID }); idx != -1 && (*existing)[idx].Name != p.Name {
+			return ErrUUIDAlreadyUsed.WithMsg("uuid ", p.UUID, " is already used by project ", (*existing)[idx].Name)
+		}
+	}
+
+	idx := slices.IndexFunc(*existing, func(e project.Project) bool { return e.Name == p.Name })
+	action := "Imported"
+
+	if idx != -1 {
+		switch opts.Conflict {
+		case ConflictFail:
+			return ErrProjectAlreadyExists.WithMsg("project ", p.Name, " already exists, use --conflict to skip, overwrite or rename it")
+		case ConflictSkip:
+			fmt.Println("Skipped", p.Name, "from", i.file, "- project already exists")
+			return nil
+		case ConflictOverwrite:
+			conflicting := (*existing)[idx]
+			if p.UUID == "" {
+				// reusing UUID keeps the usage history of the overwritten project
+				p.UUID = conflicting.UUID
+			} else if p.UUID != conflicting.UUID {
+				if err := s.Storage.Delete(conflicting.UUID); err != nil {
+					return err
+				}
+			}
+			*existing = slices.Delete(*existing, idx, idx+1)
+			action = "Overwrote"
+		case ConflictRename:
+			p.Name = freeName(*existing, p.Name)
+			if p.UUID == (*existing)[idx].UUID {
+				// kept UUID would overwrite the project it's renamed away from
+				p.UUID = ""
+			}
+		}
+	}
+
+	if err := s.Storage.Save(&p); err != nil {
+		return err
+	}
+	*existing = append(*existing, p)
+
+	if p.Name != i.project.Name {
+		fmt.Println(action, i.project.Name, "as", p.Name, "from", i.file)
+	} else {
+		fmt.Println(action, p.Name, "from", i.file)
+	}
+	if len(i.unsupported) > 0 {
+		fmt.Println("  skipped unsupported keys:", strings.Join(i.unsupported, ", "))
+	}
+
+	return nil
+}
+
+// finds first free name by suffixing it with a number, starting with name-2
+func freeName(projects []project.Project, name project.Name) project.Name {
+	for n := 2; ; n++ {
+		candidate := project.Name(fmt.Sprintf("%s-%d", name, n))
+		if !slices.ContainsFunc(projects, func(p project.Project) bool { return p.Name == candidate }) {
+			return candidate
+		}
+	}
+}
+
+// directories are not walked recursively, only yaml files directly inside are imported
+func (s *AppService) importFiles(path string) ([]string, error) {
+	entries, err := s.FileSystem.ReadDir(path)
+	if err != nil {
+		// not a directory (or doesn't exist), reading it as a file reports the latter properly
+		return []string{path}, nil
+	}
+
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		files = append(files, filepath.Join(path, entry.Name()))
+	}
+
+	slices.Sort(files)
+	return files, nil
+}
+
+// UUIDs coming from bundles are used as directory names as they are
+func validUUID(id project.UUID) bool {
+	return id != "." && id != ".." && !strings.ContainsAny(string(id), `/\`)
+}
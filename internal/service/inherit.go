@@ -0,0 +1,145 @@
+package service
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"thop/internal/problem"
+	"thop/internal/types/project"
+	"thop/internal/types/template"
+	"thop/internal/types/window"
+)
+
+const (
+	ErrTemplateCycle        problem.Key = "THOP_TEMPLATE_CYCLE"
+	ErrBaseTemplateNotFound problem.Key = "THOP_BASE_TEMPLATE_NOT_FOUND"
+)
+
+// inherit merges templates of the extends chain into the project, starting from its closest base,
+// snippets are used in each template before merging, so windows can be matched by their names
+func (s *AppService) inherit(p project.Project) (project.Project, error) {
+	var projects []project.Project
+	if p.Template.Extends != "" {
+		var err error
+		if projects, err = s.Storage.List(); err != nil {
+			return project.Project{}, err
+		}
+	}
+
+	return s.inheritFrom(p, projects)
+}
+
+// inheritFrom works as inherit with base templates looked up in already listed projects
+func (s *AppService) inheritFrom(p project.Project, projects []project.Project) (project.Project, error) {
+	merged, err := s.useSnippets(p.Template)
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	chain := []string{string(p.Name)}
+
+	for base := p.Template.Extends; base != ""; {
+		if slices.Contains(chain, string(base)) {
+			return project.Project{}, ErrTemplateCycle.WithMsg("templates extend each other: ", strings.Join(append(chain, string(base)), " -> "))
+		}
+		chain = append(chain, string(base))
+
+		// listed projects may contain sessions too, which can share the name with a template
+		i := slices.IndexFunc(projects, func(other project.Project) bool {
+			return other.Type == project.TypeTemplate && other.Name == project.Name(base)
+		})
+		if i == -1 {
+			return project.Project{}, ErrBaseTemplateNotFound.WithMsg("template ", chain[len(chain)-2], " extends ", base, ", which doesn't exist")
+		}
+
+		baseTemplate, err := s.useSnippets(projects[i].Template)
+		if err != nil {
+			return project.Project{}, err
+		}
+
+		merged = mergeTemplates(baseTemplate, merged)
+		base = projects[i].Template.Extends
+	}
+
+	// removals not matching any inherited window have nothing left to remove, windows may still
+	// be shared with the stored template when nothing was merged, so they are copied first
+	merged.Windows = slices.DeleteFunc(slices.Clone(merged.Windows), func(w window.Window) bool { return w.Remove })
+	merged.Extends = ""
+
+	p.Template = merged
+	return p, nil
+}
+
+// flagBroken marks templates that can't be resolved, so they don't look healthy until they are opened
+func (s *AppService) flagBroken(projects []project.Project) {
+	for i, p := range projects {
+		if p.Type != project.TypeTemplate {
+			continue
+		}
+		if _, err := s.inheritFrom(p, projects); err != nil {
+			projects[i].Broken = err.Error()
+		}
+	}
+}
+
+// merges child on top of base, session name is never inherited so sessions of both can run side by side
+func mergeTemplates(base template.Template, child template.Template) template.Template {
+	merged := base
+	merged.Name = child.Name
+
+	if child.Root != "" {
+		merged.Root = child.Root
+	}
+	if child.ActiveWindow != "" {
+		merged.ActiveWindow = child.ActiveWindow
+	}
+	if child.Socket != "" {
+		merged.Socket = child.Socket
+	}
+
+	merged.Commands = slices.Concat(base.Commands, child.Commands)
+
+	if len(base.Env) > 0 || len(child.Env) > 0 {
+		merged.Env = template.Env{}
+		maps.Copy(merged.Env, base.Env)
+		maps.Copy(merged.Env, child.Env)
+	}
+
+	merged.Windows = slices.Clone(base.Windows)
+	for _, w := range child.Windows {
+		i := slices.IndexFunc(merged.Windows, func(other window.Window) bool { return other.Name == w.Name })
+
+		switch {
+		case i == -1:
+			// removal is kept around, the window may come from template further down the chain
+			merged.Windows = append(merged.Windows, w)
+		case w.Remove:
+			merged.Windows = slices.Delete(merged.Windows, i, i+1)
+		default:
+			merged.Windows[i] = mergeWindows(merged.Windows[i], w)
+		}
+	}
+
+	return merged
+}
+
+func mergeWindows(base window.Window, child window.Window) window.Window {
+	merged := base
+	merged.Remove = child.Remove
+
+	if child.Root != "" {
+		merged.Root = child.Root
+	}
+	if child.Layout != "" {
+		merged.Layout = child.Layout
+	}
+
+	merged.Commands = slices.Concat(base.Commands, child.Commands)
+
+	// panes have no identity to be matched by, so they are replaced as a whole
+	if len(child.Panes) > 0 {
+		merged.Panes = child.Panes
+	}
+
+	return merged
+}
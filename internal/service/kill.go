@@ -0,0 +1,182 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"thop/internal/multiplexer"
+	"thop/internal/problem"
+	"thop/internal/types/project"
+	"time"
+)
+
+const (
+	ErrInvalidPattern      problem.Key = "THOP_INVALID_PATTERN"
+	ErrInvalidSwitchTarget problem.Key = "THOP_INVALID_SWITCH_TARGET"
+	ErrNotInsideSession    problem.Key = "THOP_NOT_INSIDE_SESSION"
+)
+
+// picksMany tells whether sessions are picked by filters or patterns, instead of by exact names
+func (o KillOptions) picksMany(names []project.Name) bool {
+	if o.All || o.Others || o.Idle > 0 {
+		return true
+	}
+	return slices.ContainsFunc(names, func(name project.Name) bool {
+		return o.Regex || strings.ContainsAny(string(name), "*?[")
+	})
+}
+
+// killMatching kills every session matching the patterns and filters once confirmed,
+// the session the user is in goes last, so the client is switched away only when it's needed
+func (s *AppService) killMatching(patterns []project.Name, opts KillOptions) error {
+	matches, err := namePatterns(patterns, opts.Regex)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := s.Multiplexer.ListActiveSessions()
+	if err != nil {
+		return err
+	}
+
+	current, err := s.Multiplexer.CurrentSession()
+	if err != nil {
+		return err
+	}
+
+	if opts.Others && current == "" {
+		// without a current session every session is one of the others, which is what --all is for
+		return ErrNotInsideSession.WithMsg("--others needs to be run inside of a tmux session")
+	}
+
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+
+	var picked []project.Project
+	var currentSession *project.Project
+	for _, session := range sessions {
+		if !matches(session) {
+			continue
+		}
+
+		if opts.Idle > 0 && (session.Session == nil || now.Sub(session.Session.Activity) < opts.Idle) {
+			continue
+		}
+
+		isCurrent := current != "" && tmuxName(session) == current
+		if isCurrent && opts.Others {
+			continue
+		}
+
+		if isCurrent {
+			currentSession = &session
+			continue
+		}
+		picked = append(picked, session)
+	}
+
+	if currentSession != nil {
+		picked = append(picked, *currentSession)
+	}
+
+	if len(picked) == 0 {
+		fmt.Println("No sessions to kill")
+		return nil
+	}
+
+	if !opts.Yes {
+		confirmed, err := s.confirmKill(picked)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("Left sessions running")
+			return nil
+		}
+	}
+
+	failures := runBatch(picked, func(p project.Project) error { return s.kill(p, opts) })
+	return batchError(failures, len(picked))
+}
+
+// tmuxName is the name the session has in tmux, listed sessions carry it, since they may be renamed
+func tmuxName(session project.Project) multiplexer.SessionName {
+	if session.Session != nil && session.Session.Name != "" {
+		return multiplexer.SessionName(session.Session.Name)
+	}
+	return multiplexer.SanitizeSessionName(string(session.Name))
+}
+
+func (s *AppService) confirmKill(sessions []project.Project) (bool, error) {
+	fmt.Println("Sessions to kill:")
+	for _, session := range sessions {
+		fmt.Println(" ", session.Name)
+	}
+
+	return s.Prompter.Confirm(fmt.Sprintf("Kill %d sessions?", len(sessions)))
+}
+
+// namePatterns builds a matcher of session names, globs have to match the whole name while
+// regular expressions match anywhere in it, no patterns match every session
+func namePatterns(patterns []project.Name, regex bool) (func(project.Project) bool, error) {
+	var matchers []func(string) bool
+
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+
+		if regex {
+			re, err := regexp.Compile(string(pattern))
+			if err != nil {
+				return nil, ErrInvalidPattern.WithMsg(err.Error())
+			}
+			matchers = append(matchers, re.MatchString)
+			continue
+		}
+
+		if _, err := path.Match(string(pattern), ""); err != nil {
+			return nil, ErrInvalidPattern.WithMsg("invalid glob ", pattern)
+		}
+		matchers = append(matchers, func(name string) bool {
+			matched, _ := path.Match(string(pattern), name)
+			return matched
+		})
+	}
+
+	return func(session project.Project) bool {
+		if len(matchers) == 0 {
+			return true
+		}
+
+		// sanitized sessions can be matched by either of their names
+		names := []string{string(session.Name), string(multiplexer.SanitizeSessionName(string(session.Name)))}
+		return slices.ContainsFunc(matchers, func(match func(string) bool) bool {
+			return slices.ContainsFunc(names, match)
+		})
+	}, nil
+}
+
+// switchAway opens the chosen project when the user is in the session about to be killed,
+// so the multiplexer doesn't have to pick a session to fall back to
+func (s *AppService) switchAway(session project.Project, target project.Name) error {
+	current, err := s.Multiplexer.CurrentSession()
+	if err != nil || current == "" {
+		return err
+	}
+
+	name, err := s.Multiplexer.ResolveSessionName(session)
+	if err != nil || name != current {
+		return err
+	}
+
+	if sessionNamed(session, target) {
+		return ErrInvalidSwitchTarget.WithMsg("can't switch to ", target, " since it's the session being killed")
+	}
+
+	return s.OpenProject(target, OpenOptions{})
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"fmt"
+	"thop/internal/types/project"
+)
+
+// ReloadOptions tweak how running sessions pick up template changes
+type ReloadOptions struct {
+	// Incremental only adds windows and panes missing in the session, instead of recreating it
+	Incremental bool
+}
+
+// ReloadProject applies the current template to the running session of the project,
+// the session is built from scratch when it's not running
+func (s *AppService) ReloadProject(name project.Name, opts ReloadOptions) error {
+	p, err := s.findOrSelect(name, "Select project to reload > ")
+	if err != nil {
+		return err
+	}
+
+	if p, err = s.withSession(p); err != nil {
+		return err
+	}
+
+	if !opts.Incremental {
+		return s.rebuild(p)
+	}
+
+	if err := s.History.Record(p); err != nil {
+		fmt.Println("Failed to record history:", err.Error())
+	}
+
+	resolved, err := s.resolve(p)
+	if err != nil {
+		return err
+	}
+
+	return s.Multiplexer.UpdateProject(resolved)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"thop/internal/problem"
+	"thop/internal/types/pane"
+	"thop/internal/types/project"
+	"thop/internal/types/template"
+	"thop/internal/types/window"
+)
+
+const (
+	ErrFailedToResolveTemplate problem.Key = "THOP_FAILED_TO_RESOLVE_TEMPLATE"
+)
+
+// resolves the template into the exact form used to build the session,
+// sessions without a template are returned as they are
+func (s *AppService) resolve(p project.Project) (project.Project, error) {
+	if p.Type != project.TypeTemplate {
+		return p, nil
+	}
+
+	p, err := s.inherit(p)
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	return expandRoots(p)
+}
+
+// resolveFrom works as resolve with base templates looked up in already listed projects
+func (s *AppService) resolveFrom(p project.Project, projects []project.Project) (project.Project, error) {
+	if p.Type != project.TypeTemplate {
+		return p, nil
+	}
+
+	p, err := s.inheritFrom(p, projects)
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	return expandRoots(p)
+}
+
+// expands roots of the template and all its windows and panes
+func expandRoots(p project.Project) (project.Project, error) {
+	// windows are modified below, so don't leak changes to the caller
+	p.Template.Windows = slices.Clone(p.Template.Windows)
+
+	root, err := expandPath(string(p.Template.Root))
+	if err != nil {
+		return project.Project{}, err
+	}
+	p.Template.Root = template.Root(root)
+
+	for i, w := range p.Template.Windows {
+		root, err := expandPath(string(w.Root))
+		if err != nil {
+			return project.Project{}, err
+		}
+		p.Template.Windows[i].Root = window.Root(root)
+
+		p.Template.Windows[i].Panes = slices.Clone(w.Panes)
+		for j, pn := range w.Panes {
+			root, err := expandPath(string(pn.Root))
+			if err != nil {
+				return project.Project{}, err
+			}
+			p.Template.Windows[i].Panes[j].Root = pane.Root(root)
+		}
+	}
+
+	return p, nil
+}
+
+// expands leading ~ and environment variables, so templates can be shared between users
+func expandPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", ErrFailedToResolveTemplate.WithMsg(err.Error())
+		}
+		path = filepath.Join(home, path[1:])
+	}
+
+	return os.ExpandEnv(path), nil
+}
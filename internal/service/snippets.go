@@ -0,0 +1,67 @@
+package service
+
+import (
+	"slices"
+	"thop/internal/problem"
+	"thop/internal/types/template"
+	"thop/internal/types/window"
+)
+
+const (
+	ErrInvalidSnippet problem.Key = "THOP_INVALID_SNIPPET"
+)
+
+// useSnippets replaces windows referencing snippets with the snippet definition,
+// root, commands, layout and panes set on the window itself override the snippet ones
+func (s *AppService) useSnippets(t template.Template) (template.Template, error) {
+	if !slices.ContainsFunc(t.Windows, func(w window.Window) bool { return w.Use != "" }) {
+		return t, nil
+	}
+
+	t.Windows = slices.Clone(t.Windows)
+	for i, w := range t.Windows {
+		if w.Use == "" {
+			continue
+		}
+
+		snippet, err := s.Storage.FindSnippet(w.Use)
+		if err != nil {
+			return template.Template{}, err
+		}
+
+		if snippet.Use != "" {
+			return template.Template{}, ErrInvalidSnippet.WithMsg("snippet ", w.Use, " can't use another snippet")
+		}
+
+		t.Windows[i] = overrideSnippet(w.Use, snippet, w)
+	}
+
+	return t, nil
+}
+
+func overrideSnippet(name window.Snippet, snippet window.Window, w window.Window) window.Window {
+	used := snippet
+	used.Remove = w.Remove
+
+	switch {
+	case w.Name != "":
+		used.Name = w.Name
+	case snippet.Name == "":
+		used.Name = window.Name(name)
+	}
+
+	if w.Root != "" {
+		used.Root = w.Root
+	}
+	if len(w.Commands) > 0 {
+		used.Commands = w.Commands
+	}
+	if w.Layout != "" {
+		used.Layout = w.Layout
+	}
+	if len(w.Panes) > 0 {
+		used.Panes = w.Panes
+	}
+
+	return used
+}
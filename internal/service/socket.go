@@ -0,0 +1,54 @@
+package service
+
+import (
+	"slices"
+	"thop/internal/multiplexer"
+	"thop/internal/types/project"
+)
+
+// listSessions lists sessions of the default server together with sessions of servers
+// templates run their sessions on, the latter are labeled with the socket they run on
+func (s *AppService) listSessions(projects []project.Project) ([]project.Project, error) {
+	sessions, err := s.Multiplexer.ListActiveSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	var sockets []string
+	for _, p := range projects {
+		if socket := s.socketOf(p, projects); socket != "" && !slices.Contains(sockets, socket) {
+			sockets = append(sockets, socket)
+		}
+	}
+
+	for _, socket := range sockets {
+		other, err := s.Multiplexer.ListSessionsOn(multiplexer.Socket(socket))
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, other...)
+	}
+
+	return sessions, nil
+}
+
+// socketOf returns socket of the server sessions of the template run on, empty for the default server,
+// base templates are looked up in the listed projects
+func (s *AppService) socketOf(p project.Project, projects []project.Project) string {
+	if p.Type != project.TypeTemplate {
+		return ""
+	}
+
+	socket := string(p.Template.Socket)
+	if p.Template.Extends != "" {
+		// socket may be inherited from the base template
+		if resolved, err := s.inheritFrom(p, projects); err == nil {
+			socket = string(resolved.Template.Socket)
+		}
+	}
+
+	if s.Config != nil && socket == s.Config.Tmux.Socket {
+		return ""
+	}
+	return socket
+}
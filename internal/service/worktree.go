@@ -0,0 +1,224 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"thop/internal/git"
+	"thop/internal/multiplexer"
+	"thop/internal/problem"
+	"thop/internal/types/pane"
+	"thop/internal/types/project"
+	"thop/internal/types/template"
+	"thop/internal/types/window"
+)
+
+const (
+	ErrNoWorktrees problem.Key = "THOP_NO_WORKTREES"
+)
+
+// opens the project in a worktree of its root, checking the branch out first when it has none yet,
+// sessions are named project/branch so they can run next to the project's own session
+func (s *AppService) openWorktree(name project.Name, opts OpenOptions) error {
+	p, err := s.findOrSelect(name, "Select project to open > ")
+	if err != nil {
+		return err
+	}
+
+	if p.Type != project.TypeTemplate {
+		return ErrNotATemplate.WithMsg(p.Name, " is not a template")
+	}
+
+	resolved, err := s.resolve(p)
+	if err != nil {
+		return err
+	}
+
+	worktrees, err := s.Git.ListWorktrees(string(resolved.Template.Root))
+	if err != nil {
+		return err
+	}
+	if len(worktrees) == 0 {
+		return ErrNoWorktrees.WithMsg(p.Name, " root is not a git repository")
+	}
+	main := worktrees[0]
+
+	if opts.Worktree == "" {
+		return s.selectWorktree(resolved, worktrees)
+	}
+
+	for _, wt := range worktrees {
+		if wt.Branch != opts.Worktree {
+			continue
+		}
+		if wt.Path == main.Path {
+			// branch checked out in the repository itself is just the project
+			return s.attach(p)
+		}
+		return s.attachWorktree(resolved, main, wt)
+	}
+
+	wt := git.Worktree{Path: worktreePath(main.Path, opts.Worktree), Branch: opts.Worktree}
+
+	exists, err := s.Git.BranchExists(main.Path, wt.Branch)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Git.AddWorktree(main.Path, wt.Path, wt.Branch, !exists); err != nil {
+		return err
+	}
+
+	return s.attachWorktree(resolved, main, wt)
+}
+
+func (s *AppService) selectWorktree(p project.Project, worktrees []git.Worktree) error {
+	var candidates []project.Project
+	for _, wt := range worktrees[1:] {
+		if wt.Bare || wt.Branch == "" {
+			continue
+		}
+
+		rebased, err := s.rebaseWorktree(p, worktrees[0], wt)
+		if err != nil {
+			return err
+		}
+		candidates = append(candidates, rebased)
+	}
+
+	if len(candidates) == 0 {
+		return ErrNoWorktrees.WithMsg(p.Name, " has no worktrees, use --worktree <branch> to create one")
+	}
+
+	selected, err := s.Selector.SelectFrom(candidates, "Select worktree to open > ")
+	if err != nil {
+		return err
+	}
+
+	return s.attach(*selected)
+}
+
+func (s *AppService) attachWorktree(p project.Project, main git.Worktree, wt git.Worktree) error {
+	rebased, err := s.rebaseWorktree(p, main, wt)
+	if err != nil {
+		return err
+	}
+
+	return s.attach(rebased)
+}
+
+// moves roots inside of the main worktree to the same place in the given one, the project
+// loses its identity, so history and session lookups treat it as a session of its own
+func (s *AppService) rebaseWorktree(p project.Project, main git.Worktree, wt git.Worktree) (project.Project, error) {
+	sessionName, err := s.Multiplexer.ResolveSessionName(p)
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	name := worktreeSessionName(sessionName, wt)
+	p.UUID = ""
+	p.Source = ""
+	p.Name = name
+	p.Template.Name = template.Name(name)
+
+	p.Template.Root = template.Root(rebasePath(string(p.Template.Root), main.Path, wt.Path))
+
+	windows := make([]window.Window, len(p.Template.Windows))
+	for i, w := range p.Template.Windows {
+		w.Root = window.Root(rebasePath(string(w.Root), main.Path, wt.Path))
+
+		panes := make([]pane.Pane, len(w.Panes))
+		for j, pn := range w.Panes {
+			pn.Root = pane.Root(rebasePath(string(pn.Root), main.Path, wt.Path))
+			panes[j] = pn
+		}
+		w.Panes = panes
+
+		windows[i] = w
+	}
+	p.Template.Windows = windows
+
+	return p, nil
+}
+
+func worktreeSessionName(sessionName multiplexer.SessionName, wt git.Worktree) project.Name {
+	return project.Name(fmt.Sprintf("%s/%s", sessionName, wt.Branch))
+}
+
+func rebasePath(path string, from string, to string) string {
+	if path == "" {
+		return path
+	}
+
+	rel, err := filepath.Rel(from, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		// outside of the repository, shared by all worktrees
+		return path
+	}
+
+	return filepath.Join(to, rel)
+}
+
+// worktrees are kept next to the repository, so they don't show up as untracked files in it
+func worktreePath(repo string, branch string) string {
+	return filepath.Join(filepath.Dir(repo), filepath.Base(repo)+".worktrees", strings.ReplaceAll(branch, "/", "-"))
+}
+
+// removes the worktree a killed session was opened in, worktrees with changes are kept
+// since removing them would lose work, sessions not opened in a worktree are ignored
+func (s *AppService) removeWorktree(session project.Project) error {
+	projects, err := s.Storage.List()
+	if err != nil {
+		return err
+	}
+
+	for _, p := range projects {
+		sessionName, err := s.Multiplexer.ResolveSessionName(p)
+		if err != nil {
+			continue
+		}
+
+		// worktree sessions are named project/branch, checked cheaply before asking git
+		if !strings.HasPrefix(string(multiplexer.SanitizeSessionName(string(session.Name))), string(sessionName)+"/") {
+			continue
+		}
+
+		root, err := expandPath(string(p.Template.Root))
+		if err != nil {
+			return err
+		}
+
+		worktrees, err := s.Git.ListWorktrees(root)
+		if err != nil || len(worktrees) == 0 {
+			continue
+		}
+
+		for _, wt := range worktrees[1:] {
+			if !sessionNamed(session, worktreeSessionName(sessionName, wt)) {
+				continue
+			}
+			return s.removeCleanWorktree(worktrees[0], wt)
+		}
+	}
+
+	return nil
+}
+
+func (s *AppService) removeCleanWorktree(main git.Worktree, wt git.Worktree) error {
+	clean, err := s.Git.IsClean(wt.Path)
+	if err != nil {
+		return err
+	}
+
+	if !clean {
+		fmt.Println("Kept worktree", wt.Path, "since it has uncommitted changes")
+		return nil
+	}
+
+	if err := s.Git.RemoveWorktree(main.Path, wt.Path); err != nil {
+		return err
+	}
+
+	fmt.Println("Removed worktree", wt.Path)
+	return nil
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"thop/internal/fsystem"
+	"thop/internal/problem"
+	"thop/internal/types/project"
+	"thop/internal/types/window"
+)
+
+const (
+	ErrReadOnlySource problem.Key = "STORAGE_READ_ONLY_SOURCE"
+)
+
+// SharedStorage reads templates from a directory managed outside of thop (git checkout, network share),
+// it uses the same layout as personal templates and is never written to
+type SharedStorage struct {
+	Name       project.Source
+	Dir        string
+	FileSystem fsystem.FileSystem
+}
+
+func (s *SharedStorage) List() ([]project.Project, error) {
+	projects, err := readTemplates(s.FileSystem, s.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range projects {
+		projects[i].Source = s.Name
+	}
+
+	return projects, nil
+}
+
+// LayeredStorage merges personal templates with shared ones, personal templates take precedence
+// over shared ones with the same name, and earlier shared sources over later ones
+type LayeredStorage struct {
+	Personal Storage
+	Shared   []*SharedStorage
+}
+
+func (s *LayeredStorage) List() ([]project.Project, error) {
+	projects, err := s.Personal.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range s.Shared {
+		shared, err := source.List()
+		if err != nil {
+			// unavailable share shouldn't make personal templates unusable
+			fmt.Fprintln(os.Stderr, "Failed to read template source", source.Name+":", err.Error())
+			continue
+		}
+
+		for _, p := range shared {
+			shadowed := slices.ContainsFunc(projects, func(other project.Project) bool { return other.Name == p.Name })
+			if !shadowed {
+				projects = append(projects, p)
+			}
+		}
+	}
+
+	return projects, nil
+}
+
+func (s *LayeredStorage) Find(name project.Name) (project.Project, error) {
+	projects, err := s.List()
+	if err != nil {
+		return project.Project{}, err
+	}
+
+	for _, p := range projects {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+
+	return project.Project{}, ErrProjectNotFound.WithMsg("project ", name, " not found")
+}
+
+func (s *LayeredStorage) Save(p *project.Project) error {
+	if p.Source != "" {
+		return ErrReadOnlySource.WithMsg("project ", p.Name, " comes from read-only source ", p.Source)
+	}
+
+	return s.Personal.Save(p)
+}
+
+// Delete removes personal templates only, shared templates can't be deleted by UUID alone
+func (s *LayeredStorage) Delete(uuid project.UUID) error {
+	return s.Personal.Delete(uuid)
+}
+
+func (s *LayeredStorage) PrepareTemplateFile(p project.Project) (string, error) {
+	if p.Source != "" {
+		return "", ErrReadOnlySource.WithMsg("project ", p.Name, " comes from read-only source ", p.Source)
+	}
+
+	return s.Personal.PrepareTemplateFile(p)
+}
+
+// FindSnippet looks up personal snippets only, shared sources contain templates alone
+func (s *LayeredStorage) FindSnippet(name window.Snippet) (window.Window, error) {
+	return s.Personal.FindSnippet(name)
+}
@@ -1,12 +1,16 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"path/filepath"
+	"strings"
 	"thop/internal/config"
 	"thop/internal/fsystem"
 	"thop/internal/problem"
 	"thop/internal/types/project"
+	"thop/internal/types/window"
 
 	"github.com/goccy/go-yaml"
 	"github.com/google/uuid"
@@ -18,6 +22,7 @@ type Storage interface {
 	Save(*project.Project) error
 	Delete(uuid project.UUID) error
 	PrepareTemplateFile(project.Project) (string, error)
+	FindSnippet(window.Snippet) (window.Window, error)
 }
 
 type YamlStorage struct {
@@ -32,11 +37,14 @@ const (
 	ErrFailedToSaveProject       problem.Key = "STORAGE_FAILED_TO_SAVE_PROJECT"
 	ErrFailedToSerializeProject  problem.Key = "STORAGE_FAILED_TO_SERIALIZE_PROJECT"
 	ErrProjectNotFound           problem.Key = "STORAGE_PROJECT_NOT_FOUND"
+	ErrSnippetNotFound           problem.Key = "STORAGE_SNIPPET_NOT_FOUND"
+	ErrFailedToReadSnippet       problem.Key = "STORAGE_FAILED_TO_READ_SNIPPET"
 )
 
 const (
 	templateFileName = "template.yaml"
 	templatesDirName = "templates"
+	snippetsDirName  = "snippets"
 )
 
 func (s *YamlStorage) List() ([]project.Project, error) {
@@ -50,7 +58,12 @@ func (s *YamlStorage) List() ([]project.Project, error) {
 		return nil, ErrFailedToCreateTemplateDir.WithMsg(err.Error())
 	}
 
-	dirs, err := s.FileSystem.ReadDir(templatesDir)
+	return readTemplates(s.FileSystem, templatesDir)
+}
+
+// reads templates stored as <dir>/<uuid>/template.yaml, broken templates are reported and skipped
+func readTemplates(fsys fsystem.FileSystem, templatesDir string) ([]project.Project, error) {
+	dirs, err := fsys.ReadDir(templatesDir)
 	if err != nil {
 		return nil, ErrFailedToReadTemplateDir.WithMsg(err.Error())
 	}
@@ -65,7 +78,7 @@ func (s *YamlStorage) List() ([]project.Project, error) {
 		dirName := dir.Name()
 
 		templateFile := filepath.Join(templatesDir, dirName, templateFileName)
-		bytes, err := s.FileSystem.ReadFile(templateFile)
+		bytes, err := fsys.ReadFile(templateFile)
 		if err != nil {
 			fmt.Println(err)
 			continue
@@ -138,3 +151,26 @@ func (s *YamlStorage) PrepareTemplateFile(p project.Project) (string, error) {
 	cfgDir := s.Config.GetConfigDir()
 	return filepath.Join(cfgDir, templatesDirName, string(p.UUID), templateFileName), nil
 }
+
+// FindSnippet reads window definition from <configdir>/snippets/<name>.yaml
+func (s *YamlStorage) FindSnippet(name window.Snippet) (window.Window, error) {
+	if name == "" || strings.ContainsAny(string(name), `/\`) || strings.HasPrefix(string(name), ".") {
+		return window.Window{}, ErrSnippetNotFound.WithMsg("invalid snippet name ", name)
+	}
+
+	snippetFile := filepath.Join(s.Config.GetConfigDir(), snippetsDirName, string(name)+".yaml")
+	bytes, err := s.FileSystem.ReadFile(snippetFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return window.Window{}, ErrSnippetNotFound.WithMsg("snippet ", name, " not found")
+	}
+	if err != nil {
+		return window.Window{}, ErrFailedToReadSnippet.WithMsg(err.Error())
+	}
+
+	var w window.Window
+	if err := yaml.Unmarshal(bytes, &w); err != nil {
+		return window.Window{}, ErrFailedToReadSnippet.WithMsg("snippet ", name, ": ", err.Error())
+	}
+
+	return w, nil
+}
@@ -6,7 +6,7 @@ type Name string
 type Root string
 
 type Pane struct {
-	Name     Name              `yaml:"name"`
-	Root     Root              `yaml:"root,omitempty"`
-	Commands []command.Command `yaml:"run,omitempty"`
+	Name     Name              `yaml:"name,omitempty" json:"name,omitempty"`
+	Root     Root              `yaml:"root,omitempty" json:"root,omitempty"`
+	Commands []command.Command `yaml:"run,omitempty" json:"run,omitempty"`
 }
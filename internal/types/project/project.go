@@ -1,24 +1,73 @@
 package project
 
 import (
+	"fmt"
 	"thop/internal/types"
 	"thop/internal/types/template"
+	"time"
 )
 
 type UUID string
 type Name string
 
+// Source names the read-only template source a project comes from, personal templates have none
+type Source string
+
 type ProjectType int
 
 const (
 	TypeTemplate ProjectType = iota // will default to TypeTemplate if not set explicitly
 	TypeTmuxSession
+	TypeDirectory // discovered directory without a template, opened with the default one
 )
 
 type Project struct {
-	UUID     UUID              `yaml:"-"`
-	Name     Name              `yaml:"name"`
-	Version  types.Version     `yaml:"version"`
-	Template template.Template `yaml:"template"`
-	Type     ProjectType       `yaml:"-"`
+	UUID     UUID              `yaml:"-" json:"uuid,omitempty"`
+	Name     Name              `yaml:"name" json:"name"`
+	Version  types.Version     `yaml:"version" json:"version"`
+	Template template.Template `yaml:"template" json:"template"`
+	Type     ProjectType       `yaml:"-" json:"-"`
+	// Running marks templates with an active session, sessions are always running
+	Running bool   `yaml:"-" json:"-"`
+	Source  Source `yaml:"-" json:"source,omitempty"`
+	// Session describes the running session, nil when it's not running
+	Session *Session `yaml:"-" json:"-"`
+	// Broken explains why the template can't be resolved, e.g. it extends a missing template
+	Broken string `yaml:"-" json:"-"`
+}
+
+// Session is metadata of a running session
+type Session struct {
+	// Name of the session in tmux, the project is named after @thop_name when the session has it
+	Name     string
+	Attached int // number of attached clients
+	Windows  int
+	Activity time.Time // last activity in the session
+	Path     string
+	// Managed marks sessions created by thop
+	Managed bool
+	// UUID of the template the session was created from
+	UUID UUID
+	// Hash of the template the session was built from
+	Hash string
+	// Stale marks sessions whose template has changed since they were built
+	Stale bool
+	// Socket of the tmux server the session runs on, empty for the default one
+	Socket string
+}
+
+// Idle formats time since the last activity in the session compactly, e.g. 5m, 3h or 2d
+func (s *Session) Idle(now time.Time) string {
+	idle := now.Sub(s.Activity)
+
+	switch {
+	case idle < time.Minute:
+		return "0m"
+	case idle < time.Hour:
+		return fmt.Sprintf("%dm", int(idle.Minutes()))
+	case idle < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(idle.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(idle.Hours()/24))
+	}
 }
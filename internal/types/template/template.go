@@ -1,6 +1,9 @@
 package template
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"thop/internal/types/command"
 	"thop/internal/types/window"
 )
@@ -9,12 +12,33 @@ type Name string
 type Root string
 type ActiveWindow string
 
+// Extends names the project this template is based on
+type Extends string
+
+// Socket is name or path of the socket of the tmux server the session runs on, default server when empty
+type Socket string
+
+// Env is set in the session environment, so it's inherited by every window and pane
+type Env map[string]string
+
 type Template struct {
 	// Template name is used to specify the session name in multiplexer,
 	// if not specified, the project name should be used
-	Name         Name              `yaml:"name,omitempty"`
-	Root         Root              `yaml:"root"`
-	Commands     []command.Command `yaml:"run,omitempty"`
-	Windows      []window.Window   `yaml:"windows"`
-	ActiveWindow ActiveWindow      `yaml:"active_window,omitempty"`
+	Name         Name              `yaml:"name,omitempty" json:"name,omitempty"`
+	Root         Root              `yaml:"root" json:"root"`
+	Commands     []command.Command `yaml:"run,omitempty" json:"run,omitempty"`
+	Windows      []window.Window   `yaml:"windows" json:"windows"`
+	ActiveWindow ActiveWindow      `yaml:"active_window,omitempty" json:"active_window,omitempty"`
+	Env          Env               `yaml:"env,omitempty" json:"env,omitempty"`
+	// Extends merges this template on top of template of another project, windows are matched by name
+	Extends Extends `yaml:"extends,omitempty" json:"extends,omitempty"`
+	Socket  Socket  `yaml:"socket,omitempty" json:"socket,omitempty"`
+}
+
+// Hash identifies the template content, so sessions built from an older version can be told apart
+func (t Template) Hash() string {
+	// marshalling a struct of strings, slices and maps can't fail, map keys are sorted
+	data, _ := json.Marshal(t)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
 }
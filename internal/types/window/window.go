@@ -8,9 +8,22 @@ import (
 type Name string
 type Root string
 
+// Snippet names a reusable window definition
+type Snippet string
+
+// Layout is one of tmux layouts (even-horizontal, main-vertical, tiled, ...) or a custom layout string
+type Layout string
+
 type Window struct {
-	Name     Name              `yaml:"name"`
-	Root     Root              `yaml:"root,omitempty"`
-	Commands []command.Command `yaml:"run,omitempty"`
-	Panes    []pane.Pane       `yaml:"panes,omitempty"`
+	Name Name `yaml:"name" json:"name"`
+	Root Root `yaml:"root,omitempty" json:"root,omitempty"`
+	// Commands are run in every pane of the window, before commands of the pane itself
+	Commands []command.Command `yaml:"run,omitempty" json:"run,omitempty"`
+	// Panes split the window further, the first pane takes place of the window's initial pane
+	Panes  []pane.Pane `yaml:"panes,omitempty" json:"panes,omitempty"`
+	Layout Layout      `yaml:"layout,omitempty" json:"layout,omitempty"`
+	// Remove drops window of the same name inherited from extended template
+	Remove bool `yaml:"remove,omitempty" json:"remove,omitempty"`
+	// Use builds the window from a snippet, fields set here override the snippet ones
+	Use Snippet `yaml:"use,omitempty" json:"use,omitempty"`
 }
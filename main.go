@@ -1,25 +1,41 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"thop/cmd"
 	"thop/internal/config"
+	"thop/internal/discovery"
 	"thop/internal/executor"
 	"thop/internal/fsystem"
+	"thop/internal/git"
+	"thop/internal/history"
 	"thop/internal/multiplexer"
+	"thop/internal/prompt"
 	"thop/internal/selector"
 	"thop/internal/service"
 	"thop/internal/storage"
+	"thop/internal/types/project"
 )
 
 func main() {
+	cmd.NewAppService = newAppService
+	cmd.Execute()
+}
+
+func newAppService(opts cmd.Options) service.Service {
 	editor := os.Getenv("EDITOR")
 	userConfigDir, err := os.UserConfigDir()
 	if err != nil {
 		panic(err)
 	}
 
+	executable, err := os.Executable()
+	if err != nil {
+		panic(err)
+	}
+
 	configPath := filepath.Join(userConfigDir, "thop")
 	tmuxSession := os.Getenv("TMUX")
 
@@ -28,26 +44,83 @@ func main() {
 		Editor:    editor,
 	}
 
-	executor := executor.ShellExecutor{}
-	fsystem := fsystem.OsFileSystem{}
+	var cmdExecutor executor.CommandExecutor = &executor.ShellExecutor{}
+	var fileSystem fsystem.FileSystem = &fsystem.OsFileSystem{}
 
-	svc := service.AppService{
-		Selector: &selector.FzfProjectSelector{E: &executor},
+	if opts.DryRun {
+		cmdExecutor = &executor.DryRunExecutor{Inner: cmdExecutor, Out: os.Stdout}
+		fileSystem = &fsystem.DryRunFileSystem{Inner: fileSystem, Out: os.Stdout}
+	}
+
+	if err := config.Load(fileSystem); err != nil {
+		fmt.Println("Failed to load config:", err.Error())
+		os.Exit(1)
+	}
+
+	if opts.Socket != "" {
+		config.Tmux.Socket = opts.Socket
+	}
+	socket := multiplexer.Socket(config.Tmux.Socket)
+
+	// entries are listed by a new thop process, it has to look at the same server
+	reloadArgs := []string{executable, "__entries"}
+	if socket != "" {
+		reloadArgs = append(reloadArgs, "--socket", string(socket))
+	}
+
+	var sharedStorages []*storage.SharedStorage
+	for _, source := range config.Sources {
+		sharedStorages = append(sharedStorages, &storage.SharedStorage{
+			Name:       project.Source(source.Name),
+			Dir:        source.Path,
+			FileSystem: fileSystem,
+		})
+	}
+
+	// discovery stays off until search paths or zoxide are configured
+	var discoverer discovery.Discoverer
+	if len(config.Discovery.Paths) > 0 || config.Discovery.Zoxide {
+		discoverer = &discovery.DirectoryDiscoverer{
+			Config:     config.Discovery,
+			FileSystem: fileSystem,
+			E:          cmdExecutor,
+		}
+	}
+
+	history := history.YamlHistory{
+		Config:     &config,
+		FileSystem: fileSystem,
+	}
+
+	return &service.AppService{
+		Selector: &selector.FzfProjectSelector{
+			E:             cmdExecutor,
+			ReloadCommand: executor.CommandLine(reloadArgs),
+			Order:         selector.NewOrdering(config.Selector.Order, &history),
+		},
 
 		Multiplexer: &multiplexer.TmuxMultiplexer{
 			ActiveTmuxSession: tmuxSession,
-			Client:            &multiplexer.TmuxClientImpl{E: &executor},
+			Client:            &multiplexer.TmuxClientImpl{E: cmdExecutor, Socket: socket},
+			Socket:            socket,
+			KillFallback:      config.Kill.Fallback,
 		},
 
-		Storage: &storage.YamlStorage{
-			Config:     &config,
-			FileSystem: &fsystem,
+		Storage: &storage.LayeredStorage{
+			Personal: &storage.YamlStorage{
+				Config:     &config,
+				FileSystem: fileSystem,
+			},
+			Shared: sharedStorages,
 		},
 
-		Config: &config,
-		E:      &executor,
-	}
+		Prompter: &prompt.TerminalPrompter{In: os.Stdin, Out: os.Stdout},
 
-	cmd.AppService = &svc
-	cmd.Execute()
+		Discoverer: discoverer,
+		Git:        &git.GitClientImpl{E: cmdExecutor},
+		History:    &history,
+		Config:     &config,
+		FileSystem: fileSystem,
+		E:          cmdExecutor,
+	}
 }
@@ -0,0 +1,64 @@
+package bundle_test
+
+import (
+	"testing"
+	"thop/internal/bundle"
+	"thop/internal/types"
+	"thop/internal/types/project"
+	"thop/internal/types/template"
+	"thop/internal/types/window"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Bundle(t *testing.T) {
+	t.Run("keeps uuids through marshal and parse", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{
+				UUID:    "1234",
+				Name:    "foo",
+				Version: types.V1,
+				Template: template.Template{
+					Root:    "/home/test",
+					Windows: []window.Window{{Name: "main"}},
+				},
+			},
+		}
+
+		// when
+		bytes, err := bundle.New(projects).Marshal()
+		assert.Nil(t, err)
+		parsed, err := bundle.Parse(bytes)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, ""+
+			"version: 1\n"+
+			"projects:\n"+
+			"- uuid: \"1234\"\n"+
+			"  name: foo\n"+
+			"  version: 1\n"+
+			"  template:\n"+
+			"    root: /home/test\n"+
+			"    windows:\n"+
+			"    - name: main\n", string(bytes))
+		assert.Equal(t, projects, parsed.List())
+	})
+
+	t.Run("returns error for unsupported version", func(t *testing.T) {
+		// when
+		_, err := bundle.Parse([]byte("version: 2\nprojects: []\n"))
+
+		// then
+		assert.True(t, bundle.ErrUnsupportedBundle.Equal(err))
+	})
+
+	t.Run("returns error for invalid yaml", func(t *testing.T) {
+		// when
+		_, err := bundle.Parse([]byte("projects: ["))
+
+		// then
+		assert.True(t, bundle.ErrInvalidBundle.Equal(err))
+	})
+}
@@ -0,0 +1,205 @@
+package config_test
+
+import (
+	"io/fs"
+	"testing"
+	"thop/internal/config"
+	"thop/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Load(t *testing.T) {
+	t.Run("uses defaults when config file doesn't exist", func(t *testing.T) {
+		// given
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/config.yaml").Return([]byte(nil), fs.ErrNotExist).Once()
+
+		cfg := config.Config{ConfigDir: "/foo/bar"}
+
+		// when
+		err := cfg.Load(fsMock)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, config.OrderAlphabetical, cfg.Selector.Order)
+		assert.Equal(t, config.FallbackSwitch, cfg.Kill.Fallback)
+		fsMock.AssertExpectations(t)
+	})
+
+	t.Run("reads selector order from config file", func(t *testing.T) {
+		// given
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/config.yaml").Return([]byte("selector:\n  order: frecency\n"), nil).Once()
+
+		cfg := config.Config{ConfigDir: "/foo/bar"}
+
+		// when
+		err := cfg.Load(fsMock)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, config.OrderFrecency, cfg.Selector.Order)
+	})
+
+	t.Run("returns error for unknown selector order", func(t *testing.T) {
+		// given
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/config.yaml").Return([]byte("selector:\n  order: random\n"), nil).Once()
+
+		cfg := config.Config{ConfigDir: "/foo/bar"}
+
+		// when
+		err := cfg.Load(fsMock)
+
+		// then
+		assert.True(t, config.ErrInvalidConfig.Equal(err))
+	})
+
+	t.Run("reads template sources", func(t *testing.T) {
+		// given
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/config.yaml").Return([]byte("sources:\n  - name: team\n    path: /mnt/templates\n"), nil).Once()
+
+		cfg := config.Config{ConfigDir: "/foo/bar"}
+
+		// when
+		err := cfg.Load(fsMock)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []config.SourceConfig{{Name: "team", Path: "/mnt/templates"}}, cfg.Sources)
+	})
+
+	t.Run("returns error for incomplete or duplicate template sources", func(t *testing.T) {
+		for _, sources := range []string{
+			"sources:\n  - name: team\n",
+			"sources:\n  - path: /mnt/templates\n",
+			"sources:\n  - name: team\n    path: /a\n  - name: team\n    path: /b\n",
+		} {
+			// given
+			fsMock := new(test.MockFileSystem)
+			fsMock.On("ReadFile", "/foo/bar/config.yaml").Return([]byte(sources), nil).Once()
+
+			cfg := config.Config{ConfigDir: "/foo/bar"}
+
+			// when
+			err := cfg.Load(fsMock)
+
+			// then
+			assert.True(t, config.ErrInvalidConfig.Equal(err), sources)
+		}
+	})
+
+	t.Run("reads discovery with defaults for depth and ignore", func(t *testing.T) {
+		// given
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/config.yaml").Return([]byte("discovery:\n  paths:\n    - path: /code\n    - path: /work\n      depth: 2\n  git_only: true\n"), nil).Once()
+
+		cfg := config.Config{ConfigDir: "/foo/bar"}
+
+		// when
+		err := cfg.Load(fsMock)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []config.SearchPath{{Path: "/code", Depth: 1}, {Path: "/work", Depth: 2}}, cfg.Discovery.Paths)
+		assert.Equal(t, []string{".*", "node_modules"}, cfg.Discovery.Ignore)
+		assert.True(t, cfg.Discovery.GitOnly)
+	})
+
+	t.Run("replaces default ignore patterns", func(t *testing.T) {
+		// given
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/config.yaml").Return([]byte("discovery:\n  ignore: []\n"), nil).Once()
+
+		cfg := config.Config{ConfigDir: "/foo/bar"}
+
+		// when
+		err := cfg.Load(fsMock)
+
+		// then
+		assert.Nil(t, err)
+		assert.Empty(t, cfg.Discovery.Ignore)
+	})
+
+	t.Run("returns error for invalid discovery", func(t *testing.T) {
+		for _, discovery := range []string{
+			"discovery:\n  paths:\n    - depth: 2\n",
+			"discovery:\n  paths:\n    - path: /code\n      depth: -1\n",
+			"discovery:\n  ignore: ['[']\n",
+		} {
+			// given
+			fsMock := new(test.MockFileSystem)
+			fsMock.On("ReadFile", "/foo/bar/config.yaml").Return([]byte(discovery), nil).Once()
+
+			cfg := config.Config{ConfigDir: "/foo/bar"}
+
+			// when
+			err := cfg.Load(fsMock)
+
+			// then
+			assert.True(t, config.ErrInvalidConfig.Equal(err), discovery)
+		}
+	})
+	t.Run("reads kill fallback", func(t *testing.T) {
+		// given
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/config.yaml").Return([]byte("kill:\n  fallback: detach\n"), nil).Once()
+
+		cfg := config.Config{ConfigDir: "/foo/bar"}
+
+		// when
+		err := cfg.Load(fsMock)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, config.FallbackDetach, cfg.Kill.Fallback)
+	})
+
+	t.Run("returns error for unknown kill fallback", func(t *testing.T) {
+		// given
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/config.yaml").Return([]byte("kill:\n  fallback: exit\n"), nil).Once()
+
+		cfg := config.Config{ConfigDir: "/foo/bar"}
+
+		// when
+		err := cfg.Load(fsMock)
+
+		// then
+		assert.True(t, config.ErrInvalidConfig.Equal(err))
+	})
+
+	t.Run("reads tmux socket", func(t *testing.T) {
+		// given
+		t.Setenv(config.SocketEnv, "")
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/config.yaml").Return([]byte("tmux:\n  socket: work\n"), nil).Once()
+
+		cfg := config.Config{ConfigDir: "/foo/bar"}
+
+		// when
+		err := cfg.Load(fsMock)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "work", cfg.Tmux.Socket)
+	})
+
+	t.Run("tmux socket from environment wins over config file", func(t *testing.T) {
+		// given
+		t.Setenv(config.SocketEnv, "/tmp/tmux-work")
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/config.yaml").Return([]byte("tmux:\n  socket: work\n"), nil).Once()
+
+		cfg := config.Config{ConfigDir: "/foo/bar"}
+
+		// when
+		err := cfg.Load(fsMock)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "/tmp/tmux-work", cfg.Tmux.Socket)
+	})
+}
@@ -0,0 +1,180 @@
+package discovery_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"testing"
+	"thop/internal/config"
+	"thop/internal/discovery"
+	"thop/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fake tree of directories, entries ending with "/" are directories, the rest are files
+func newTree(tree map[string][]string) *test.MockFileSystem {
+	fsMock := new(test.MockFileSystem)
+
+	for dir, children := range tree {
+		var entries []os.DirEntry
+		for _, child := range children {
+			entry := new(test.MockDirEntry)
+			name, isDir := child, false
+			if child[len(child)-1] == '/' {
+				name, isDir = child[:len(child)-1], true
+			}
+			entry.On("Name").Return(name)
+			entry.On("IsDir").Return(isDir)
+			entries = append(entries, entry)
+		}
+		fsMock.On("ReadDir", dir).Return(entries, nil)
+	}
+
+	fsMock.On("ReadDir", mock.Anything).Return([]os.DirEntry(nil), fs.ErrNotExist)
+	return fsMock
+}
+
+var codeTree = map[string][]string{
+	"/code":               {"api/", "notes.txt", "tools/", ".cache/", "node_modules/"},
+	"/code/api":           {".git/", "src/"},
+	"/code/api/src":       {},
+	"/code/tools":         {"cli/", "scripts/"},
+	"/code/tools/cli":     {".git"},
+	"/code/tools/scripts": {},
+	"/code/.cache":        {},
+	"/code/node_modules":  {},
+}
+
+func Test_Discover(t *testing.T) {
+	t.Run("lists direct children by default", func(t *testing.T) {
+		// given
+		d := discovery.DirectoryDiscoverer{
+			Config: config.DiscoveryConfig{
+				Paths:  []config.SearchPath{{Path: "/code", Depth: 1}},
+				Ignore: []string{".*", "node_modules"},
+			},
+			FileSystem: newTree(codeTree),
+		}
+
+		// when
+		dirs, err := d.Discover()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/code/api", "/code/tools"}, dirs)
+	})
+
+	t.Run("descends to depth without entering git repositories", func(t *testing.T) {
+		// given
+		d := discovery.DirectoryDiscoverer{
+			Config: config.DiscoveryConfig{
+				Paths:  []config.SearchPath{{Path: "/code", Depth: 3}},
+				Ignore: []string{".*", "node_modules"},
+			},
+			FileSystem: newTree(codeTree),
+		}
+
+		// when
+		dirs, err := d.Discover()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/code/api", "/code/tools", "/code/tools/cli", "/code/tools/scripts"}, dirs)
+	})
+
+	t.Run("lists only git repositories", func(t *testing.T) {
+		// given
+		d := discovery.DirectoryDiscoverer{
+			Config: config.DiscoveryConfig{
+				Paths:   []config.SearchPath{{Path: "/code", Depth: 2}},
+				GitOnly: true,
+			},
+			FileSystem: newTree(codeTree),
+		}
+
+		// when
+		dirs, err := d.Discover()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/code/api", "/code/tools/cli"}, dirs)
+	})
+
+	t.Run("reports unreadable search path and continues", func(t *testing.T) {
+		// given
+		var warnings bytes.Buffer
+		d := discovery.DirectoryDiscoverer{
+			Config: config.DiscoveryConfig{
+				Paths:  []config.SearchPath{{Path: "/missing", Depth: 1}, {Path: "/code", Depth: 1}},
+				Ignore: []string{".*", "node_modules", "tools"},
+			},
+			FileSystem: newTree(codeTree),
+			Warnings:   &warnings,
+		}
+
+		// when
+		dirs, err := d.Discover()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/code/api"}, dirs)
+		assert.Contains(t, warnings.String(), "/missing")
+	})
+
+	t.Run("adds zoxide directories without duplicates", func(t *testing.T) {
+		// given
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Run(func(args mock.Arguments) {
+			cmd := args.Get(0).(*exec.Cmd)
+			assert.Equal(t, []string{"zoxide", "query", "--list"}, cmd.Args)
+		}).Return("/home/foo/dotfiles\n/code/api\n", 0, nil).Once()
+
+		d := discovery.DirectoryDiscoverer{
+			Config: config.DiscoveryConfig{
+				Paths:  []config.SearchPath{{Path: "/code", Depth: 1}},
+				Ignore: []string{".*", "node_modules"},
+				Zoxide: true,
+			},
+			FileSystem: newTree(codeTree),
+			E:          execMock,
+		}
+
+		// when
+		dirs, err := d.Discover()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/code/api", "/code/tools", "/home/foo/dotfiles"}, dirs)
+		execMock.AssertExpectations(t)
+	})
+
+	t.Run("reports failing zoxide and keeps scanned directories", func(t *testing.T) {
+		// given
+		var warnings bytes.Buffer
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Return("", 127, errors.New("not found")).Once()
+
+		d := discovery.DirectoryDiscoverer{
+			Config: config.DiscoveryConfig{
+				Paths:  []config.SearchPath{{Path: "/code", Depth: 1}},
+				Ignore: []string{".*", "node_modules"},
+				Zoxide: true,
+			},
+			FileSystem: newTree(codeTree),
+			E:          execMock,
+			Warnings:   &warnings,
+		}
+
+		// when
+		dirs, err := d.Discover()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/code/api", "/code/tools"}, dirs)
+		assert.Contains(t, warnings.String(), "failed to query zoxide")
+	})
+}
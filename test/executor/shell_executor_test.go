@@ -0,0 +1,112 @@
+package executor_test
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"thop/internal/executor"
+	"thop/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_DryRunExecutor(t *testing.T) {
+	t.Run("prints commands changing state instead of running them", func(t *testing.T) {
+		// given
+		var out bytes.Buffer
+		inner := new(test.MockExecutor)
+
+		e := executor.DryRunExecutor{Inner: inner, Out: &out}
+
+		// when
+		output, exitCode, err := e.Execute(exec.Command("tmux", "new-session", "-d", "-s", "my session"))
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "", output)
+		assert.Equal(t, 0, exitCode)
+		assert.Equal(t, "[dry-run] tmux new-session -d -s 'my session'\n", out.String())
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("runs commands which only read state", func(t *testing.T) {
+		// given
+		var out bytes.Buffer
+		inner := new(test.MockExecutor)
+		inner.On("Execute", mock.Anything).Return("foo\n", 0, nil).Once()
+
+		e := executor.DryRunExecutor{Inner: inner, Out: &out}
+
+		// when
+		output, _, err := e.Execute(exec.Command("tmux", "list-sessions", "-F", "#S"))
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "foo\n", output)
+		assert.Empty(t, out.String())
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("matches nested read-only subcommands", func(t *testing.T) {
+		// given
+		var out bytes.Buffer
+		inner := new(test.MockExecutor)
+		inner.On("Execute", mock.Anything).Return("worktree /foo\n", 0, nil).Once()
+
+		e := executor.DryRunExecutor{Inner: inner, Out: &out}
+
+		// when
+		_, _, listErr := e.Execute(exec.Command("git", "worktree", "list", "--porcelain"))
+		_, _, addErr := e.Execute(exec.Command("git", "worktree", "add", "-b", "foo", "/foo"))
+
+		// then
+		assert.Nil(t, listErr)
+		assert.Nil(t, addErr)
+		assert.Equal(t, "[dry-run] git worktree add -b foo /foo\n", out.String())
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("skips values of global flags when matching subcommands", func(t *testing.T) {
+		// given
+		var out bytes.Buffer
+		inner := new(test.MockExecutor)
+		inner.On("Execute", mock.Anything).Return("", 0, nil).Twice()
+
+		e := executor.DryRunExecutor{Inner: inner, Out: &out}
+
+		// when
+		_, _, nameErr := e.Execute(exec.Command("tmux", "-L", "work", "has-session", "-t", "=foo"))
+		_, _, pathErr := e.Execute(exec.Command("tmux", "-S", "/tmp/sock", "-u", "list-sessions", "-F", "#S"))
+		_, _, newErr := e.Execute(exec.Command("tmux", "-L", "list-sessions", "new-session", "-d", "-s", "foo"))
+
+		// then
+		assert.Nil(t, nameErr)
+		assert.Nil(t, pathErr)
+		assert.Nil(t, newErr)
+		assert.Equal(t, "[dry-run] tmux -L list-sessions new-session -d -s foo\n", out.String())
+		inner.AssertExpectations(t)
+	})
+
+	t.Run("prints interactive commands", func(t *testing.T) {
+		// given
+		var out bytes.Buffer
+		inner := new(test.MockExecutor)
+
+		e := executor.DryRunExecutor{Inner: inner, Out: &out}
+
+		// when
+		_, err := e.ExecuteInteractive(exec.Command("vim", "/home/test/template.yaml"))
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "[dry-run] vim /home/test/template.yaml\n", out.String())
+	})
+}
+
+func Test_CommandLine(t *testing.T) {
+	t.Run("quotes arguments only when needed", func(t *testing.T) {
+		// expect
+		assert.Equal(t, "tmux send-keys -t foo:main 'it'\\''s' C-m ''", executor.CommandLine([]string{"tmux", "send-keys", "-t", "foo:main", "it's", "C-m", ""}))
+	})
+}
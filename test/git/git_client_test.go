@@ -0,0 +1,127 @@
+package git_test
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"thop/internal/git"
+	"thop/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_ListWorktrees(t *testing.T) {
+	t.Run("parses porcelain output", func(t *testing.T) {
+		// given
+		output := "worktree /code/api\nHEAD 1234\nbranch refs/heads/main\n\n" +
+			"worktree /code/api.worktrees/feature-login\nHEAD 5678\nbranch refs/heads/feature/login\n\n" +
+			"worktree /code/api.worktrees/detached\nHEAD 9abc\ndetached\n\n"
+
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Run(func(args mock.Arguments) {
+			cmd := args.Get(0).(*exec.Cmd)
+			assert.Equal(t, []string{"git", "worktree", "list", "--porcelain"}, cmd.Args)
+			assert.Equal(t, "/code/api", cmd.Dir)
+		}).Return(output, 0, nil).Once()
+
+		client := git.GitClientImpl{E: execMock}
+
+		// when
+		worktrees, err := client.ListWorktrees("/code/api")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []git.Worktree{
+			{Path: "/code/api", Branch: "main"},
+			{Path: "/code/api.worktrees/feature-login", Branch: "feature/login"},
+			{Path: "/code/api.worktrees/detached"},
+		}, worktrees)
+	})
+
+	t.Run("returns error outside of repository", func(t *testing.T) {
+		// given
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Return("", 128, errors.New("exit status 128")).Once()
+
+		client := git.GitClientImpl{E: execMock}
+
+		// when
+		_, err := client.ListWorktrees("/tmp")
+
+		// then
+		assert.True(t, git.ErrFailedToListWorktrees.Equal(err))
+	})
+}
+
+func Test_BranchExists(t *testing.T) {
+	t.Run("checks local and remote branches", func(t *testing.T) {
+		// given
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Run(func(args mock.Arguments) {
+			cmd := args.Get(0).(*exec.Cmd)
+			assert.Equal(t, []string{"git", "for-each-ref", "--format=%(refname)", "refs/heads/foo", "refs/remotes/*/foo"}, cmd.Args)
+		}).Return("refs/remotes/origin/foo\n", 0, nil).Once()
+		execMock.On("Execute", mock.Anything).Return("", 0, nil).Once()
+
+		client := git.GitClientImpl{E: execMock}
+
+		// when
+		remote, remoteErr := client.BranchExists("/code/api", "foo")
+		missing, missingErr := client.BranchExists("/code/api", "bar")
+
+		// then
+		assert.Nil(t, remoteErr)
+		assert.Nil(t, missingErr)
+		assert.True(t, remote)
+		assert.False(t, missing)
+	})
+}
+
+func Test_AddWorktree(t *testing.T) {
+	t.Run("checks out existing or new branch", func(t *testing.T) {
+		// given
+		var executed [][]string
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Run(func(args mock.Arguments) {
+			cmd := args.Get(0).(*exec.Cmd)
+			assert.Equal(t, "/code/api", cmd.Dir)
+			executed = append(executed, cmd.Args)
+		}).Return("", 0, nil)
+
+		client := git.GitClientImpl{E: execMock}
+
+		// when
+		existingErr := client.AddWorktree("/code/api", "/code/api.worktrees/foo", "foo", false)
+		newErr := client.AddWorktree("/code/api", "/code/api.worktrees/bar", "bar", true)
+
+		// then
+		assert.Nil(t, existingErr)
+		assert.Nil(t, newErr)
+		assert.Equal(t, [][]string{
+			{"git", "worktree", "add", "/code/api.worktrees/foo", "foo"},
+			{"git", "worktree", "add", "-b", "bar", "/code/api.worktrees/bar"},
+		}, executed)
+	})
+}
+
+func Test_IsClean(t *testing.T) {
+	t.Run("reports changes in worktree", func(t *testing.T) {
+		// given
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Return("", 0, nil).Once()
+		execMock.On("Execute", mock.Anything).Return("?? notes.txt\n", 0, nil).Once()
+
+		client := git.GitClientImpl{E: execMock}
+
+		// when
+		clean, cleanErr := client.IsClean("/code/api.worktrees/foo")
+		dirty, dirtyErr := client.IsClean("/code/api.worktrees/foo")
+
+		// then
+		assert.Nil(t, cleanErr)
+		assert.Nil(t, dirtyErr)
+		assert.True(t, clean)
+		assert.False(t, dirty)
+	})
+}
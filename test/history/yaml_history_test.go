@@ -0,0 +1,119 @@
+package history_test
+
+import (
+	"io/fs"
+	"testing"
+	"thop/internal/config"
+	"thop/internal/history"
+	"thop/internal/types/project"
+	"thop/test"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Entries(t *testing.T) {
+	cfg := &config.Config{ConfigDir: "/foo/bar"}
+
+	t.Run("returns empty list when history file doesn't exist", func(t *testing.T) {
+		// given
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/history.yaml").Return([]byte(nil), fs.ErrNotExist).Once()
+
+		h := history.YamlHistory{Config: cfg, FileSystem: fsMock}
+
+		// when
+		entries, err := h.Entries()
+
+		// then
+		assert.Nil(t, err)
+		assert.Empty(t, entries)
+		fsMock.AssertExpectations(t)
+	})
+
+	t.Run("returns entries ordered by last usage", func(t *testing.T) {
+		// given
+		file := "entries:\n" +
+			"- uuid: \"1234\"\n  name: foo\n  count: 3\n  last_used: 2025-01-01T10:00:00Z\n" +
+			"- name: bar\n  count: 1\n  last_used: 2025-01-02T10:00:00Z\n"
+
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/history.yaml").Return([]byte(file), nil).Once()
+
+		h := history.YamlHistory{Config: cfg, FileSystem: fsMock}
+
+		// when
+		entries, err := h.Entries()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []history.Entry{
+			{Name: "bar", Count: 1, LastUsed: time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)},
+			{UUID: "1234", Name: "foo", Count: 3, LastUsed: time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)},
+		}, entries)
+	})
+}
+
+func Test_Record(t *testing.T) {
+	cfg := &config.Config{ConfigDir: "/foo/bar"}
+	now := time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC)
+
+	t.Run("increments count of existing entry", func(t *testing.T) {
+		// given
+		file := "entries:\n- uuid: \"1234\"\n  name: foo\n  count: 3\n  last_used: 2025-01-01T10:00:00Z\n"
+		var written string
+
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/history.yaml").Return([]byte(file), nil).Once()
+		fsMock.On("MkdirAll", "/foo/bar").Return(nil).Once()
+		fsMock.On("WriteFile", "/foo/bar/history.yaml", mock.Anything).Run(func(args mock.Arguments) {
+			written = string(args.Get(1).([]byte))
+		}).Return(nil).Once()
+
+		h := history.YamlHistory{Config: cfg, FileSystem: fsMock, Now: func() time.Time { return now }}
+
+		// when
+		err := h.Record(project.Project{UUID: "1234", Name: "foo"})
+
+		// then
+		assert.Nil(t, err)
+		assert.Contains(t, written, "count: 4")
+		assert.Contains(t, written, "last_used: 2025-01-03T10:00:00Z")
+		fsMock.AssertExpectations(t)
+	})
+
+	t.Run("adds new entry for sessions without template", func(t *testing.T) {
+		// given
+		var written string
+
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadFile", "/foo/bar/history.yaml").Return([]byte(nil), fs.ErrNotExist).Once()
+		fsMock.On("MkdirAll", "/foo/bar").Return(nil).Once()
+		fsMock.On("WriteFile", "/foo/bar/history.yaml", mock.Anything).Run(func(args mock.Arguments) {
+			written = string(args.Get(1).([]byte))
+		}).Return(nil).Once()
+
+		h := history.YamlHistory{Config: cfg, FileSystem: fsMock, Now: func() time.Time { return now }}
+
+		// when
+		err := h.Record(project.Project{Name: "bar", Type: project.TypeTmuxSession})
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "entries:\n- name: bar\n  count: 1\n  last_used: 2025-01-03T10:00:00Z\n", written)
+	})
+}
+
+func Test_Frecency(t *testing.T) {
+	now := time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC)
+
+	t.Run("recent usage weighs more than old one", func(t *testing.T) {
+		// given
+		recent := history.Entry{Count: 2, LastUsed: now.Add(-time.Minute)}
+		old := history.Entry{Count: 10, LastUsed: now.Add(-30 * 24 * time.Hour)}
+
+		// expect
+		assert.Greater(t, recent.Frecency(now), old.Frecency(now))
+	})
+}
@@ -0,0 +1,159 @@
+package importer_test
+
+import (
+	"testing"
+	"thop/internal/importer"
+	"thop/internal/types"
+	"thop/internal/types/command"
+	"thop/internal/types/pane"
+	"thop/internal/types/project"
+	"thop/internal/types/template"
+	"thop/internal/types/window"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Convert_Tmuxinator(t *testing.T) {
+	t.Run("converts windows, panes, layout and pre_window", func(t *testing.T) {
+		// given
+		data := []byte(`
+name: blog
+root: ~/code/blog
+pre_window: nvm use 18
+startup_window: editor
+windows:
+  - editor:
+      layout: main-vertical
+      root: src
+      synchronize: after
+      panes:
+        - vim
+        - logs:
+            - cd log
+            - tail -f development.log
+        -
+  - server: bundle exec rails s
+  - shell:
+`)
+
+		// when
+		result, err := importer.Convert(importer.SourceTmuxinator, "fallback", data)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, project.Project{
+			Name:    "blog",
+			Version: types.V1,
+			Template: template.Template{
+				Root:     "~/code/blog",
+				Commands: []command.Command{"nvm use 18"},
+				Windows: []window.Window{
+					{
+						Name:   "editor",
+						Root:   "~/code/blog/src",
+						Layout: "main-vertical",
+						Panes: []pane.Pane{
+							{Commands: []command.Command{"vim"}},
+							{Name: "logs", Commands: []command.Command{"cd log", "tail -f development.log"}},
+							{},
+						},
+					},
+					{Name: "server", Commands: []command.Command{"bundle exec rails s"}},
+					{Name: "shell"},
+				},
+			},
+		}, result.Project)
+		assert.Equal(t, []string{"startup_window", "windows.editor.synchronize"}, result.Unsupported)
+	})
+
+	t.Run("uses fallback name and default root", func(t *testing.T) {
+		// when
+		result, err := importer.Convert(importer.SourceTmuxinator, "fallback", []byte("windows:\n  - main:\n"))
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, project.Name("fallback"), result.Project.Name)
+		assert.Equal(t, template.Root("~"), result.Project.Template.Root)
+	})
+
+	t.Run("returns error for malformed window", func(t *testing.T) {
+		// when
+		_, err := importer.Convert(importer.SourceTmuxinator, "foo", []byte("windows:\n  - a: ls\n    b: ls\n"))
+
+		// then
+		assert.True(t, importer.ErrInvalidConfig.Equal(err))
+	})
+}
+
+func Test_Convert_Tmuxp(t *testing.T) {
+	t.Run("converts windows, panes, environment and shell_command_before", func(t *testing.T) {
+		// given
+		data := []byte(`
+session_name: api
+start_directory: /srv/api
+shell_command_before:
+  - cmd: source .env
+environment:
+  PORT: 8080
+before_script: ./bootstrap.sh
+windows:
+  - window_name: dev
+    layout: tiled
+    focus: true
+    panes:
+      - shell_command:
+          - make watch
+        start_directory: web
+      - blank
+      - htop
+  - panes:
+      - null
+`)
+
+		// when
+		result, err := importer.Convert(importer.SourceTmuxp, "fallback", data)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, project.Project{
+			Name:    "api",
+			Version: types.V1,
+			Template: template.Template{
+				Root:     "/srv/api",
+				Commands: []command.Command{"source .env"},
+				Env:      template.Env{"PORT": "8080"},
+				Windows: []window.Window{
+					{
+						Name:   "dev",
+						Layout: "tiled",
+						Panes: []pane.Pane{
+							{Root: "/srv/api/web", Commands: []command.Command{"make watch"}},
+							{},
+							{Commands: []command.Command{"htop"}},
+						},
+					},
+					{Name: "window-2", Panes: []pane.Pane{{}}},
+				},
+			},
+		}, result.Project)
+		assert.Equal(t, []string{"before_script", "windows.dev.focus"}, result.Unsupported)
+	})
+}
+
+func Test_Convert(t *testing.T) {
+	t.Run("returns error for unknown source", func(t *testing.T) {
+		// when
+		_, err := importer.Convert("teamocil", "foo", []byte("name: foo\n"))
+
+		// then
+		assert.True(t, importer.ErrUnknownSource.Equal(err))
+	})
+
+	t.Run("returns error for invalid yaml", func(t *testing.T) {
+		// when
+		_, err := importer.Convert(importer.SourceTmuxp, "foo", []byte("broken: ["))
+
+		// then
+		assert.True(t, importer.ErrInvalidConfig.Equal(err))
+	})
+}
@@ -0,0 +1,66 @@
+package importer_test
+
+import (
+	"testing"
+	"thop/internal/importer"
+	"thop/internal/types/pane"
+	"thop/internal/types/project"
+	"thop/internal/types/template"
+	"thop/internal/types/window"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseRootMapping(t *testing.T) {
+	t.Run("parses old=new mapping", func(t *testing.T) {
+		// when
+		m, err := importer.ParseRootMapping("/home/alice/=/home/bob")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, importer.RootMapping{From: "/home/alice", To: "/home/bob"}, m)
+	})
+
+	t.Run("returns error for malformed mapping", func(t *testing.T) {
+		for _, s := range []string{"/home/alice", "=/home/bob", "/home/alice="} {
+			// when
+			_, err := importer.ParseRootMapping(s)
+
+			// then
+			assert.True(t, importer.ErrInvalidRootMapping.Equal(err), s)
+		}
+	})
+}
+
+func Test_MapRoots(t *testing.T) {
+	t.Run("rewrites template, window and pane roots on path boundaries", func(t *testing.T) {
+		// given
+		p := project.Project{
+			Name: "foo",
+			Template: template.Template{
+				Root: "/home/alice",
+				Windows: []window.Window{
+					{Name: "main", Root: "/home/alice/src", Panes: []pane.Pane{{Root: "/home/alice/src/web"}, {}}},
+					{Name: "other", Root: "/home/alicia/src"},
+				},
+			},
+		}
+		mappings := []importer.RootMapping{
+			{From: "/home/alice/src", To: "/srv/src"},
+			{From: "/home/alice", To: "/home/bob"},
+		}
+
+		// when
+		mapped := importer.MapRoots(p, mappings)
+
+		// then
+		assert.Equal(t, template.Root("/home/bob"), mapped.Template.Root)
+		assert.Equal(t, window.Root("/srv/src"), mapped.Template.Windows[0].Root)
+		assert.Equal(t, pane.Root("/srv/src/web"), mapped.Template.Windows[0].Panes[0].Root)
+		assert.Equal(t, pane.Root(""), mapped.Template.Windows[0].Panes[1].Root)
+		assert.Equal(t, window.Root("/home/alicia/src"), mapped.Template.Windows[1].Root)
+
+		// and original is left untouched
+		assert.Equal(t, window.Root("/home/alice/src"), p.Template.Windows[0].Root)
+	})
+}
@@ -1,10 +1,16 @@
 package multiplexer_test
 
 import (
+	"encoding/json"
 	"errors"
+	"flag"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 	"thop/internal/multiplexer"
+	"thop/internal/types/template"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -99,6 +105,25 @@ func Test_Client_SwitchSession(t *testing.T) {
 
 }
 
+func Test_Client_DetachClient(t *testing.T) {
+	t.Run("detaches current client", func(t *testing.T) {
+		// given
+		executor := new(MockCommandExecutor)
+		executor.On("Execute", mock.Anything).Return("", 0, nil).Once()
+
+		client := multiplexer.TmuxClientImpl{
+			E: executor,
+		}
+
+		// when
+		err := client.DetachClient()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{{"tmux", "detach-client"}}, executor.ExecutedCommands)
+	})
+}
+
 func Test_Client_HasSession(t *testing.T) {
 	t.Run("returns error if session name is empty", func(t *testing.T) {
 		// given
@@ -118,7 +143,7 @@ func Test_Client_HasSession(t *testing.T) {
 		mockExecutor := new(MockCommandExecutor)
 		mockExecutor.On("Execute", mock.Anything).Return("", 1, errors.New("exit code 1"))
 		expectedCmd := [][]string{
-			{"tmux", "has-session", "-t", "mysession"},
+			{"tmux", "has-session", "-t", "=mysession"},
 		}
 
 		client := multiplexer.TmuxClientImpl{
@@ -139,7 +164,7 @@ func Test_Client_HasSession(t *testing.T) {
 		mockExecutor := new(MockCommandExecutor)
 		mockExecutor.On("Execute", mock.Anything).Return("", 0, nil)
 		expectedCmd := [][]string{
-			{"tmux", "has-session", "-t", "mysession"},
+			{"tmux", "has-session", "-t", "=mysession"},
 		}
 
 		client := multiplexer.TmuxClientImpl{
@@ -164,15 +189,15 @@ func Test_Client_NewSession(t *testing.T) {
 		}
 
 		// expect
-		err := client.NewSession("", "root", "win", "")
+		err := client.NewSession("", "root", "win", "", nil)
 		assert.NotNil(t, err, "expected error for empty session name")
 
 		// and
-		err = client.NewSession("sess", "", "win", "")
+		err = client.NewSession("sess", "", "win", "", nil)
 		assert.NotNil(t, err, "expected error for empty session root")
 
 		// and
-		err = client.NewSession("sess", "root", "", "")
+		err = client.NewSession("sess", "root", "", "", nil)
 		assert.NotNil(t, err, "expected error for empty window name")
 	})
 
@@ -200,7 +225,7 @@ func Test_Client_NewSession(t *testing.T) {
 		}
 
 		// when
-		err := client.NewSession("mysession", "/home/test", "main", "/project")
+		err := client.NewSession("mysession", "/home/test", "main", "/project", nil)
 
 		// then
 		assert.NotNil(t, err)
@@ -231,7 +256,7 @@ func Test_Client_NewSession(t *testing.T) {
 		}
 
 		// when
-		err := client.NewSession("mysession", "/home/test", "main", "/project")
+		err := client.NewSession("mysession", "/home/test", "main", "/project", nil)
 
 		// then
 		assert.Nil(t, err)
@@ -261,7 +286,84 @@ func Test_Client_NewSession(t *testing.T) {
 		}
 
 		// when
-		err := client.NewSession("mysession", "/home/test", "main", "")
+		err := client.NewSession("mysession", "/home/test", "main", "", nil)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, expectedCmd, executor.ExecutedCommands)
+	})
+}
+
+func Test_Client_NewSession_Env(t *testing.T) {
+	t.Run("sets environment sorted by key", func(t *testing.T) {
+		// given
+		executor := new(MockCommandExecutor)
+		executor.On("Execute", mock.Anything).Return("", 0, nil)
+		expectedCmd := [][]string{
+			{
+				"tmux",
+				"new-session",
+				"-d",
+				"-s",
+				"mysession",
+				"-c",
+				"/home/test",
+				"-n",
+				"main",
+				"-e",
+				"A=1",
+				"-e",
+				"B=two words",
+			},
+		}
+
+		client := multiplexer.TmuxClientImpl{
+			E: executor,
+		}
+
+		// when
+		err := client.NewSession("mysession", "/home/test", "main", "", template.Env{"B": "two words", "A": "1"})
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, expectedCmd, executor.ExecutedCommands)
+	})
+}
+
+func Test_Client_SplitWindow(t *testing.T) {
+	t.Run("returns error when missing required fields", func(t *testing.T) {
+		// given
+		client := multiplexer.TmuxClientImpl{
+			E: nil,
+		}
+
+		// expect
+		err := client.SplitWindow("", "win", "/root")
+		assert.NotNil(t, err, "expected error for empty session name")
+
+		// and
+		err = client.SplitWindow("sess", "", "/root")
+		assert.NotNil(t, err, "expected error for empty window name")
+
+		// and
+		err = client.SplitWindow("sess", "win", "")
+		assert.NotNil(t, err, "expected error for empty root")
+	})
+
+	t.Run("splits window", func(t *testing.T) {
+		// given
+		executor := new(MockCommandExecutor)
+		executor.On("Execute", mock.Anything).Return("", 0, nil)
+		expectedCmd := [][]string{
+			{"tmux", "split-window", "-t", "mysession:main", "-c", "/project"},
+		}
+
+		client := multiplexer.TmuxClientImpl{
+			E: executor,
+		}
+
+		// when
+		err := client.SplitWindow("mysession", "main", "/project")
 
 		// then
 		assert.Nil(t, err)
@@ -269,6 +371,44 @@ func Test_Client_NewSession(t *testing.T) {
 	})
 }
 
+func Test_Client_SelectLayout(t *testing.T) {
+	t.Run("selects layout", func(t *testing.T) {
+		// given
+		executor := new(MockCommandExecutor)
+		executor.On("Execute", mock.Anything).Return("", 0, nil)
+		expectedCmd := [][]string{
+			{"tmux", "select-layout", "-t", "mysession:main", "main-vertical"},
+		}
+
+		client := multiplexer.TmuxClientImpl{
+			E: executor,
+		}
+
+		// when
+		err := client.SelectLayout("mysession", "main", "main-vertical")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, expectedCmd, executor.ExecutedCommands)
+	})
+
+	t.Run("returns error when tmux fails", func(t *testing.T) {
+		// given
+		executor := new(MockCommandExecutor)
+		executor.On("Execute", mock.Anything).Return("", 1, errors.New("bad layout"))
+
+		client := multiplexer.TmuxClientImpl{
+			E: executor,
+		}
+
+		// when
+		err := client.SelectLayout("mysession", "main", "nope")
+
+		// then
+		assert.True(t, multiplexer.ErrFailedToSelectLayout.Equal(err))
+	})
+}
+
 func Test_TmuxClient_SendKeys(t *testing.T) {
 	t.Run("returns error when missing required fields", func(t *testing.T) {
 		// given
@@ -398,14 +538,147 @@ func Test_Client_NewWindow(t *testing.T) {
 	})
 }
 
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// parser of tmux output is checked against golden files, run with -update after changing the format
 func Test_Client_ListSessions(t *testing.T) {
-	t.Run("returns list of sessions", func(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/list_sessions/*.txt")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, inputs)
+
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			// given
+			output, err := os.ReadFile(input)
+			assert.Nil(t, err)
+
+			executor := new(MockCommandExecutor)
+			executor.On("Execute", mock.Anything).Return(string(output), 0, nil).Once()
+
+			client := multiplexer.TmuxClientImpl{
+				E: executor,
+			}
+
+			// when
+			sessions, err := client.ListSessions()
+
+			// then
+			assert.Nil(t, err)
+
+			actual, err := json.MarshalIndent(sessions, "", "  ")
+			assert.Nil(t, err)
+
+			golden := strings.TrimSuffix(input, ".txt") + ".golden"
+			if *update {
+				assert.Nil(t, os.WriteFile(golden, append(actual, '\n'), 0644))
+			}
+
+			expected, err := os.ReadFile(golden)
+			assert.Nil(t, err)
+			assert.Equal(t, string(expected), string(actual)+"\n")
+		})
+	}
+
+	t.Run("queries sessions with delimited format", func(t *testing.T) {
+		// given
+		executor := new(MockCommandExecutor)
+		executor.On("Execute", mock.Anything).Return("", 0, nil).Once()
+
+		client := multiplexer.TmuxClientImpl{
+			E: executor,
+		}
+
+		// when
+		_, err := client.ListSessions()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{{"tmux", "-u", "list-sessions", "-F",
+			"#{session_id}\x1f#{session_name}\x1f#{session_attached}\x1f#{session_created}\x1f#{session_activity}\x1f#{session_windows}\x1f#{session_path}\x1f#{@thop_name}\x1f#{@thop_uuid}\x1f#{@thop_hash}",
+		}}, executor.ExecutedCommands)
+	})
+
+	t.Run("returns no sessions when server is not running", func(t *testing.T) {
+		for _, stderr := range []string{
+			"no server running on /tmp/tmux-1000/default\n",
+			"error connecting to /tmp/tmux-1000/default (No such file or directory)\n",
+		} {
+			// given
+			executor := new(MockCommandExecutor)
+			executor.On("Execute", mock.Anything).Return("", 1, &exec.ExitError{Stderr: []byte(stderr)}).Once()
+
+			client := multiplexer.TmuxClientImpl{
+				E: executor,
+			}
+
+			// when
+			sessions, err := client.ListSessions()
+
+			// then
+			assert.Nil(t, err, stderr)
+			assert.Empty(t, sessions, stderr)
+		}
+	})
+
+	t.Run("returns error for unexpected output", func(t *testing.T) {
+		for _, output := range []string{
+			"$0\x1ffoo\n",
+			"$0\x1ffoo\x1fyes\x1f1792428202\x1f1792428202\x1f1\x1f/tmp\x1f\x1f\x1f\n",
+		} {
+			// given
+			executor := new(MockCommandExecutor)
+			executor.On("Execute", mock.Anything).Return(output, 0, nil).Once()
+
+			client := multiplexer.TmuxClientImpl{
+				E: executor,
+			}
+
+			// when
+			_, err := client.ListSessions()
+
+			// then
+			assert.True(t, multiplexer.ErrFailedToListSessions.Equal(err), output)
+		}
+	})
+
+	t.Run("returns mapped error if command fails", func(t *testing.T) {
 		// given
 		executor := new(MockCommandExecutor)
-		executor.On("Execute", mock.Anything).Return("foo\nbar\nbaz\n", 0, nil).Once()
+		executor.On("Execute", mock.Anything).Return("", 1, &exec.ExitError{Stderr: []byte("server exited unexpectedly\n")}).Once()
+
+		client := multiplexer.TmuxClientImpl{
+			E: executor,
+		}
+
+		// when
+		_, err := client.ListSessions()
 
+		// then
+		assert.True(t, multiplexer.ErrFailedToListSessions.Equal(err))
+		executor.AssertExpectations(t)
+	})
+}
+
+func Test_KillSession(t *testing.T) {
+	t.Run("returns error if session name is empty", func(t *testing.T) {
+		// given
+		client := multiplexer.TmuxClientImpl{
+			E: nil,
+		}
+
+		// when
+		err := client.KillSession("")
+
+		// then
+		assert.True(t, multiplexer.ErrInvalidTemplateArgs.Equal(err))
+	})
+
+	t.Run("kills session", func(t *testing.T) {
+		// given
+		executor := new(MockCommandExecutor)
+		executor.On("Execute", mock.Anything).Return("", 0, nil)
 		expectedCmd := [][]string{
-			{"tmux", "list-sessions", "-F", "#S"},
+			{"tmux", "kill-session", "-t", "=mysession"},
 		}
 
 		client := multiplexer.TmuxClientImpl{
@@ -413,13 +686,32 @@ func Test_Client_ListSessions(t *testing.T) {
 		}
 
 		// when
-		sessions, err := client.ListSessions()
+		err := client.KillSession("mysession")
 
 		// then
 		assert.Nil(t, err)
-		assert.Equal(t, []multiplexer.SessionName{"foo", "bar", "baz"}, sessions)
 		assert.Equal(t, expectedCmd, executor.ExecutedCommands)
 	})
+}
+
+func Test_Client_DisplayMessage(t *testing.T) {
+	t.Run("returns expanded format without trailing newline", func(t *testing.T) {
+		// given
+		executor := new(MockCommandExecutor)
+		executor.On("Execute", mock.Anything).Return("mysession\n", 0, nil).Once()
+
+		client := multiplexer.TmuxClientImpl{
+			E: executor,
+		}
+
+		// when
+		output, err := client.DisplayMessage("#S")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "mysession", output)
+		assert.Equal(t, [][]string{{"tmux", "-u", "display-message", "-p", "#S"}}, executor.ExecutedCommands)
+	})
 
 	t.Run("returns mapped error if command fails", func(t *testing.T) {
 		// given
@@ -431,83 +723,183 @@ func Test_Client_ListSessions(t *testing.T) {
 		}
 
 		// when
-		_, err := client.ListSessions()
+		_, err := client.DisplayMessage("#S")
 
 		// then
-		assert.True(t, multiplexer.ErrFailedToListSessions.Equal(err))
-		executor.AssertExpectations(t)
+		assert.True(t, multiplexer.ErrFailedToDisplayMessage.Equal(err))
 	})
 }
 
-func Test_IsTmuxServerRunning(t *testing.T) {
-	t.Run("returns true if tmux server is running", func(t *testing.T) {
+func Test_Client_SessionPath(t *testing.T) {
+	t.Run("returns path of exactly matched session", func(t *testing.T) {
 		// given
 		executor := new(MockCommandExecutor)
-		executor.On("Execute", mock.Anything).Return("tmux", 0, nil).Once()
+		executor.On("Execute", mock.Anything).Return("/code/api\n", 0, nil).Once()
 
 		client := multiplexer.TmuxClientImpl{
 			E: executor,
 		}
 
 		// when
-		running := client.IsTmuxServerRunning()
+		output, err := client.SessionPath("api")
 
 		// then
-		assert.True(t, running)
-		assert.Equal(t, executor.ExecutedCommands, [][]string{{"tmux", "run"}})
-		executor.AssertExpectations(t)
+		assert.Nil(t, err)
+		assert.Equal(t, "/code/api", output)
+		assert.Equal(t, [][]string{{"tmux", "-u", "display-message", "-p", "-t", "=api:", "#{session_path}"}}, executor.ExecutedCommands)
 	})
+}
 
-	t.Run("returns false if tmux server is not running", func(t *testing.T) {
+func Test_Client_SetSessionOptions(t *testing.T) {
+	t.Run("sets options of exactly matched session in one command", func(t *testing.T) {
 		// given
 		executor := new(MockCommandExecutor)
-		executor.On("Execute", mock.Anything).Return("tmux", 1, errors.New("exit code 1")).Once()
+		executor.On("Execute", mock.Anything).Return("", 0, nil).Once()
 
 		client := multiplexer.TmuxClientImpl{
 			E: executor,
 		}
 
 		// when
-		running := client.IsTmuxServerRunning()
+		err := client.SetSessionOptions("my_app", []multiplexer.Option{
+			{Name: "@thop_name", Value: "my.app"},
+			{Name: "@thop_uuid", Value: "1234"},
+		})
 
 		// then
-		assert.False(t, running)
-		assert.Equal(t, executor.ExecutedCommands, [][]string{{"tmux", "run"}})
-		executor.AssertExpectations(t)
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{{
+			"tmux", "set-option", "-t", "=my_app:", "@thop_name", "my.app",
+			";", "set-option", "-t", "=my_app:", "@thop_uuid", "1234",
+		}}, executor.ExecutedCommands)
 	})
 }
 
-func Test_KillSession(t *testing.T) {
-	t.Run("returns error if session name is empty", func(t *testing.T) {
+func Test_Client_RenameSession(t *testing.T) {
+	t.Run("renames exactly matched session", func(t *testing.T) {
 		// given
+		executor := new(MockCommandExecutor)
+		executor.On("Execute", mock.Anything).Return("", 0, nil).Once()
+
 		client := multiplexer.TmuxClientImpl{
-			E: nil,
+			E: executor,
 		}
 
 		// when
-		err := client.KillSession("")
+		err := client.RenameSession("foo_thop_rebuild", "foo")
 
 		// then
-		assert.True(t, multiplexer.ErrInvalidTemplateArgs.Equal(err))
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{{"tmux", "rename-session", "-t", "=foo_thop_rebuild", "foo"}}, executor.ExecutedCommands)
 	})
 
-	t.Run("kills session", func(t *testing.T) {
+	t.Run("returns error when tmux fails", func(t *testing.T) {
 		// given
 		executor := new(MockCommandExecutor)
-		executor.On("Execute", mock.Anything).Return("", 0, nil)
-		expectedCmd := [][]string{
-			{"tmux", "kill-session", "-t", "mysession"},
+		executor.On("Execute", mock.Anything).Return("", 1, errors.New("duplicate session: foo")).Once()
+
+		client := multiplexer.TmuxClientImpl{
+			E: executor,
 		}
 
+		// when
+		err := client.RenameSession("foo_thop_rebuild", "foo")
+
+		// then
+		assert.True(t, multiplexer.ErrFailedToRenameSession.Equal(err))
+	})
+}
+
+func Test_Client_ListWindows(t *testing.T) {
+	t.Run("lists windows of exactly matched session with their pane count", func(t *testing.T) {
+		// given
+		executor := new(MockCommandExecutor)
+		executor.On("Execute", mock.Anything).Return("editor\x1f2\nlogs and stuff\x1f1\n", 0, nil).Once()
+
 		client := multiplexer.TmuxClientImpl{
 			E: executor,
 		}
 
 		// when
-		err := client.KillSession("mysession")
+		windows, err := client.ListWindows("api")
 
 		// then
 		assert.Nil(t, err)
-		assert.Equal(t, expectedCmd, executor.ExecutedCommands)
+		assert.Equal(t, []multiplexer.SessionWindow{{Name: "editor", Panes: 2}, {Name: "logs and stuff", Panes: 1}}, windows)
+		assert.Equal(t, [][]string{{"tmux", "-u", "list-windows", "-t", "=api:", "-F", "#{window_name}\x1f#{window_panes}"}}, executor.ExecutedCommands)
+	})
+
+	t.Run("returns error for malformed output", func(t *testing.T) {
+		// given
+		executor := new(MockCommandExecutor)
+		executor.On("Execute", mock.Anything).Return("editor_2\n", 0, nil).Once()
+
+		client := multiplexer.TmuxClientImpl{
+			E: executor,
+		}
+
+		// when
+		_, err := client.ListWindows("api")
+
+		// then
+		assert.True(t, multiplexer.ErrFailedToListWindows.Equal(err))
 	})
 }
+
+func Test_Client_Socket(t *testing.T) {
+	t.Run("passes socket name or path to every command", func(t *testing.T) {
+		for socket, args := range map[multiplexer.Socket][]string{
+			"work":           {"-L", "work"},
+			"/tmp/tmux-work": {"-S", "/tmp/tmux-work"},
+			"./sockets/work": {"-S", "./sockets/work"},
+		} {
+			// given
+			executor := new(MockCommandExecutor)
+			executor.On("Execute", mock.Anything).Return("", 0, nil)
+
+			client := multiplexer.TmuxClientImpl{
+				E:      executor,
+				Socket: socket,
+			}
+
+			// when
+			_, err := client.ListSessions()
+			assert.Nil(t, err)
+			err = client.SetSessionOptions("api", []multiplexer.Option{{Name: "@thop_uuid", Value: "1234"}})
+			assert.Nil(t, err)
+
+			// then
+			assert.Equal(t, [][]string{
+				append(append([]string{"tmux"}, args...), "-u", "list-sessions", "-F", executor.ExecutedCommands[0][len(args)+4]),
+				append(append([]string{"tmux"}, args...), "set-option", "-t", "=api:", "@thop_uuid", "1234"),
+			}, executor.ExecutedCommands, socket)
+		}
+	})
+
+	t.Run("client on another socket keeps the executor", func(t *testing.T) {
+		// given
+		executor := new(MockCommandExecutor)
+		executor.On("Execute", mock.Anything).Return("", 0, nil).Once()
+
+		client := multiplexer.TmuxClientImpl{E: executor}
+
+		// when
+		err := client.OnSocket("work").KillSession("api")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{{"tmux", "-L", "work", "kill-session", "-t", "=api"}}, executor.ExecutedCommands)
+	})
+}
+
+func Test_Socket_Serves(t *testing.T) {
+	tmuxEnv := "/tmp/tmux-1000/work,1234,0"
+
+	assert.False(t, multiplexer.Socket("work").Serves(""))
+	assert.True(t, multiplexer.Socket("").Serves(tmuxEnv))
+	assert.False(t, multiplexer.Socket("").Serves(""))
+	assert.True(t, multiplexer.Socket("work").Serves(tmuxEnv))
+	assert.True(t, multiplexer.Socket("/tmp/tmux-1000/work").Serves(tmuxEnv))
+	assert.False(t, multiplexer.Socket("default").Serves(tmuxEnv))
+	assert.False(t, multiplexer.Socket("/tmp/other/work").Serves(tmuxEnv))
+}
@@ -2,11 +2,14 @@ package multiplexer_test
 
 import (
 	"testing"
+	"thop/internal/config"
 	"thop/internal/multiplexer"
 	"thop/internal/types/command"
+	"thop/internal/types/pane"
 	"thop/internal/types/project"
 	"thop/internal/types/template"
 	"thop/internal/types/window"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -36,8 +39,9 @@ func (m *MockTmuxClient) NewSession(
 	root template.Root,
 	windowName window.Name,
 	windowRoot window.Root,
+	env template.Env,
 ) error {
-	args := m.Called(session, root, windowName, windowRoot)
+	args := m.Called(session, root, windowName, windowRoot, env)
 	return args.Error(0)
 }
 
@@ -51,6 +55,24 @@ func (m *MockTmuxClient) NewWindow(
 	return args.Error(0)
 }
 
+func (m *MockTmuxClient) SplitWindow(
+	session multiplexer.SessionName,
+	windowName window.Name,
+	root pane.Root,
+) error {
+	args := m.Called(session, windowName, root)
+	return args.Error(0)
+}
+
+func (m *MockTmuxClient) SelectLayout(
+	session multiplexer.SessionName,
+	windowName window.Name,
+	layout window.Layout,
+) error {
+	args := m.Called(session, windowName, layout)
+	return args.Error(0)
+}
+
 func (m *MockTmuxClient) SendKeys(
 	session multiplexer.SessionName,
 	windowName window.Name,
@@ -60,14 +82,24 @@ func (m *MockTmuxClient) SendKeys(
 	return args.Error(0)
 }
 
-func (m *MockTmuxClient) ListSessions() ([]multiplexer.SessionName, error) {
+func (m *MockTmuxClient) ListSessions() ([]multiplexer.Session, error) {
 	args := m.Called()
-	return args.Get(0).([]multiplexer.SessionName), args.Error(1)
+	return args.Get(0).([]multiplexer.Session), args.Error(1)
 }
 
-func (m *MockTmuxClient) IsTmuxServerRunning() bool {
+func (m *MockTmuxClient) SetSessionOptions(session multiplexer.SessionName, options []multiplexer.Option) error {
+	args := m.Called(session, options)
+	return args.Error(0)
+}
+
+func (m *MockTmuxClient) ListWindows(session multiplexer.SessionName) ([]multiplexer.SessionWindow, error) {
+	args := m.Called(session)
+	return args.Get(0).([]multiplexer.SessionWindow), args.Error(1)
+}
+
+func (m *MockTmuxClient) DetachClient() error {
 	args := m.Called()
-	return args.Bool(0)
+	return args.Error(0)
 }
 
 func (m *MockTmuxClient) KillSession(session multiplexer.SessionName) error {
@@ -75,6 +107,26 @@ func (m *MockTmuxClient) KillSession(session multiplexer.SessionName) error {
 	return args.Error(0)
 }
 
+func (m *MockTmuxClient) RenameSession(from multiplexer.SessionName, to multiplexer.SessionName) error {
+	args := m.Called(from, to)
+	return args.Error(0)
+}
+
+func (m *MockTmuxClient) DisplayMessage(format string) (string, error) {
+	args := m.Called(format)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTmuxClient) SessionPath(session multiplexer.SessionName) (string, error) {
+	args := m.Called(session)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTmuxClient) OnSocket(socket multiplexer.Socket) multiplexer.TmuxClient {
+	args := m.Called(socket)
+	return args.Get(0).(multiplexer.TmuxClient)
+}
+
 func Test_AttachProject(t *testing.T) {
 	t.Run("assembles and attaches to session if it doesn't exist", func(t *testing.T) {
 		// given
@@ -107,7 +159,12 @@ func Test_AttachProject(t *testing.T) {
 
 		mockClient := new(MockTmuxClient)
 		mockClient.On("HasSession", sessionName).Return(false, nil).Once()
-		mockClient.On("NewSession", sessionName, root, window1Name, window1Root).Return(nil).Once()
+		mockClient.On("NewSession", sessionName, root, window1Name, window1Root, template.Env(nil)).Return(nil).Once()
+		mockClient.On("SetSessionOptions", sessionName, []multiplexer.Option{
+			{Name: "@thop_name", Value: "foo"},
+			{Name: "@thop_uuid", Value: "foo"},
+			{Name: "@thop_hash", Value: project.Template.Hash()},
+		}).Return(nil).Once()
 		mockClient.On("NewWindow", sessionName, root, window2Name, window2Root).Return(nil).Once()
 		mockClient.On("SendKeys", sessionName, window1Name, command.Command("echo hello")).Return(nil).Once()
 		mockClient.On("SendKeys", sessionName, window2Name, command.Command("echo hello")).Return(nil).Once()
@@ -194,7 +251,12 @@ func Test_AttachProject(t *testing.T) {
 
 		mockClient := new(MockTmuxClient)
 		mockClient.On("HasSession", sessionName).Return(false, nil).Once()
-		mockClient.On("NewSession", sessionName, root, window1Name, window1Root).Return(nil).Once()
+		mockClient.On("NewSession", sessionName, root, window1Name, window1Root, template.Env(nil)).Return(nil).Once()
+		mockClient.On("SetSessionOptions", sessionName, []multiplexer.Option{
+			{Name: "@thop_name", Value: "foo"},
+			{Name: "@thop_uuid", Value: "foo"},
+			{Name: "@thop_hash", Value: project.Template.Hash()},
+		}).Return(nil).Once()
 		mockClient.On("NewWindow", sessionName, root, window2Name, window2Root).Return(nil).Once()
 		mockClient.On("SendKeys", sessionName, window1Name, command.Command("echo hello")).Return(nil).Once()
 		mockClient.On("SendKeys", sessionName, window2Name, command.Command("echo hello")).Return(nil).Once()
@@ -270,7 +332,12 @@ func Test_AttachProject(t *testing.T) {
 
 		mockClient := new(MockTmuxClient)
 		mockClient.On("HasSession", multiplexer.SessionName("bar")).Return(false, nil).Once()
-		mockClient.On("NewSession", multiplexer.SessionName("bar"), template.Root("/home/test"), window.Name("main"), window.Root("")).Return(nil).Once()
+		mockClient.On("NewSession", multiplexer.SessionName("bar"), template.Root("/home/test"), window.Name("main"), window.Root(""), template.Env(nil)).Return(nil).Once()
+		mockClient.On("SetSessionOptions", multiplexer.SessionName("bar"), []multiplexer.Option{
+			{Name: "@thop_name", Value: "bar"},
+			{Name: "@thop_uuid", Value: "foo"},
+			{Name: "@thop_hash", Value: project.Template.Hash()},
+		}).Return(nil).Once()
 		mockClient.On("AttachSession", multiplexer.SessionName("bar")).Return(nil).Once()
 
 		multiplexer := multiplexer.TmuxMultiplexer{
@@ -285,6 +352,94 @@ func Test_AttachProject(t *testing.T) {
 		mockClient.AssertExpectations(t)
 	})
 
+	t.Run("attaches to session on the server of template socket from inside of another server", func(t *testing.T) {
+		// given
+		project := project.Project{
+			UUID: "foo",
+			Name: "foo",
+			Template: template.Template{
+				Root:    "/home/test",
+				Socket:  "work",
+				Windows: []window.Window{{Name: "main"}},
+			},
+		}
+
+		defaultClient := new(MockTmuxClient)
+		workClient := new(MockTmuxClient)
+		defaultClient.On("OnSocket", multiplexer.Socket("work")).Return(workClient).Once()
+		workClient.On("HasSession", multiplexer.SessionName("foo")).Return(true, nil).Once()
+		workClient.On("AttachSession", multiplexer.SessionName("foo")).Return(nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{
+			Client:            defaultClient,
+			ActiveTmuxSession: "/tmp/tmux-1000/default,1,0",
+		}
+
+		// when
+		err := m.AttachProject(project)
+
+		// then
+		assert.Nil(t, err)
+		defaultClient.AssertExpectations(t)
+		workClient.AssertExpectations(t)
+	})
+
+	t.Run("switches to session on the server of template socket from inside of it", func(t *testing.T) {
+		// given
+		project := project.Project{
+			UUID: "foo",
+			Name: "foo",
+			Template: template.Template{
+				Root:    "/home/test",
+				Socket:  "work",
+				Windows: []window.Window{{Name: "main"}},
+			},
+		}
+
+		defaultClient := new(MockTmuxClient)
+		workClient := new(MockTmuxClient)
+		defaultClient.On("OnSocket", multiplexer.Socket("work")).Return(workClient).Once()
+		workClient.On("HasSession", multiplexer.SessionName("foo")).Return(true, nil).Once()
+		workClient.On("SwitchSession", multiplexer.SessionName("foo")).Return(nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{
+			Client:            defaultClient,
+			ActiveTmuxSession: "/tmp/tmux-1000/work,1,0",
+		}
+
+		// when
+		err := m.AttachProject(project)
+
+		// then
+		assert.Nil(t, err)
+		workClient.AssertExpectations(t)
+	})
+
+	t.Run("switches to renamed session of the template instead of building a new one", func(t *testing.T) {
+		// given
+		p := project.Project{
+			UUID:     "foo",
+			Name:     "foo",
+			Template: template.Template{Root: "/home/test", Windows: []window.Window{{Name: "main"}}},
+			Running:  true,
+			Session:  &project.Session{Name: "renamed", UUID: "foo"},
+		}
+
+		mockClient := new(MockTmuxClient)
+		mockClient.On("HasSession", multiplexer.SessionName("renamed")).Return(true, nil).Once()
+		mockClient.On("SwitchSession", multiplexer.SessionName("renamed")).Return(nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: "/tmp/tmux-1000/default,1,0"}
+
+		// when
+		err := m.AttachProject(p)
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertNotCalled(t, "NewSession", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockClient.AssertExpectations(t)
+	})
+
 	t.Run("returns error if project has no name", func(t *testing.T) {
 		multiplexer := multiplexer.TmuxMultiplexer{
 			Client: nil,
@@ -296,10 +451,10 @@ func Test_AttachProject(t *testing.T) {
 }
 
 func Test_ListActiveSessions(t *testing.T) {
-	t.Run("returns empty list if tmux server is not running", func(t *testing.T) {
+	t.Run("returns empty list if there are no sessions", func(t *testing.T) {
 		// given
 		mockClient := new(MockTmuxClient)
-		mockClient.On("IsTmuxServerRunning").Return(false).Once()
+		mockClient.On("ListSessions").Return([]multiplexer.Session(nil), nil).Once()
 
 		m := multiplexer.TmuxMultiplexer{
 			Client: mockClient,
@@ -317,8 +472,7 @@ func Test_ListActiveSessions(t *testing.T) {
 	t.Run("returns empty list if client returns empty list", func(t *testing.T) {
 		// given
 		mockClient := new(MockTmuxClient)
-		mockClient.On("ListSessions").Return([]multiplexer.SessionName{}, nil).Once()
-		mockClient.On("IsTmuxServerRunning").Return(true).Once()
+		mockClient.On("ListSessions").Return([]multiplexer.Session{}, nil).Once()
 
 		multiplexer := multiplexer.TmuxMultiplexer{
 			Client: mockClient,
@@ -336,8 +490,7 @@ func Test_ListActiveSessions(t *testing.T) {
 	t.Run("returns list of session type projects", func(t *testing.T) {
 		// given
 		mockClient := new(MockTmuxClient)
-		mockClient.On("ListSessions").Return([]multiplexer.SessionName{"foo", "bar"}, nil).Once()
-		mockClient.On("IsTmuxServerRunning").Return(true).Once()
+		mockClient.On("ListSessions").Return([]multiplexer.Session{{Name: "foo"}, {Name: "bar"}}, nil).Once()
 
 		multiplexer := multiplexer.TmuxMultiplexer{
 			Client: mockClient,
@@ -348,9 +501,740 @@ func Test_ListActiveSessions(t *testing.T) {
 
 		// then
 		assert.Nil(t, err)
-		for i, session := range []project.Project{{Name: "foo", Type: project.TypeTmuxSession}, {Name: "bar", Type: project.TypeTmuxSession}} {
+		for i, session := range []project.Project{
+			{Name: "foo", Type: project.TypeTmuxSession, Session: &project.Session{Name: "foo"}},
+			{Name: "bar", Type: project.TypeTmuxSession, Session: &project.Session{Name: "bar"}},
+		} {
 			assert.Equal(t, session, sessions[i])
 		}
 		mockClient.AssertExpectations(t)
 	})
+	t.Run("carries metadata of the running session", func(t *testing.T) {
+		// given
+		activity := time.Unix(1792428202, 0).UTC()
+		mockClient := new(MockTmuxClient)
+		mockClient.On("ListSessions").Return([]multiplexer.Session{{
+			Name:        "foo",
+			ProjectName: "foo",
+			UUID:        "1234",
+			Attached:    1,
+			Activity:    activity,
+			Windows:     3,
+			Path:        "/home/test/foo",
+		}}, nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient}
+
+		// when
+		sessions, err := m.ListActiveSessions()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []project.Project{{
+			Name: "foo",
+			Type: project.TypeTmuxSession,
+			Session: &project.Session{
+				Name:     "foo",
+				Attached: 1,
+				Windows:  3,
+				Activity: activity,
+				Path:     "/home/test/foo",
+				Managed:  true,
+				UUID:     "1234",
+			},
+		}}, sessions)
+	})
+}
+
+func Test_ListSessionsOn(t *testing.T) {
+	t.Run("labels sessions with the socket of their server", func(t *testing.T) {
+		// given
+		defaultClient := new(MockTmuxClient)
+		workClient := new(MockTmuxClient)
+		defaultClient.On("OnSocket", multiplexer.Socket("work")).Return(workClient).Once()
+		workClient.On("ListSessions").Return([]multiplexer.Session{{Name: "foo", Windows: 1}}, nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: defaultClient}
+
+		// when
+		sessions, err := m.ListSessionsOn("work")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []project.Project{{
+			Name:    "foo",
+			Type:    project.TypeTmuxSession,
+			Session: &project.Session{Name: "foo", Windows: 1, Socket: "work"},
+		}}, sessions)
+		workClient.AssertExpectations(t)
+	})
+
+	t.Run("doesn't list the multiplexer's own server twice", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, Socket: "work"}
+
+		// when
+		sessions, err := m.ListSessionsOn("work")
+
+		// then
+		assert.Nil(t, err)
+		assert.Nil(t, sessions)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func Test_SessionNames(t *testing.T) {
+	t.Run("sanitizes dotted and pathy names", func(t *testing.T) {
+		for name, expected := range map[string]multiplexer.SessionName{
+			"foo":                "foo",
+			"my.app":             "my_app",
+			"/home/me/.dotfiles": "/home/me/_dotfiles",
+			"host:8080":          "host_8080",
+			"api/feature/v1.2.3": "api/feature/v1_2_3",
+		} {
+			// when
+			resolved, err := (&multiplexer.TmuxMultiplexer{}).ResolveSessionName(project.Project{Name: project.Name(name)})
+
+			// then
+			assert.Nil(t, err)
+			assert.Equal(t, expected, resolved, name)
+		}
+	})
+
+	t.Run("lists sanitized sessions under their original name", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("ListSessions").Return([]multiplexer.Session{{Name: "my_app", ProjectName: "my.app"}, {Name: "foo"}}, nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient}
+
+		// when
+		sessions, err := m.ListActiveSessions()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []project.Project{
+			{Name: "my.app", Type: project.TypeTmuxSession, Session: &project.Session{Name: "my_app", Managed: true}},
+			{Name: "foo", Type: project.TypeTmuxSession, Session: &project.Session{Name: "foo"}},
+		}, sessions)
+	})
+
+	t.Run("creates sanitized session and remembers the original name", func(t *testing.T) {
+		// given
+		p := project.Project{
+			Name: "my.app",
+			Template: template.Template{
+				Root:    "/code/my.app",
+				Windows: []window.Window{{Name: "shell"}},
+			},
+		}
+
+		m := multiplexer.TmuxMultiplexer{}
+
+		// when
+		commands, err := m.Plan(p)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{
+			{"tmux", "new-session", "-d", "-s", "my_app", "-c", "/code/my.app", "-n", "shell"},
+			{"tmux", "set-option", "-t", "=my_app:", "@thop_name", "my.app"},
+		}, commands)
+	})
+
+	t.Run("tags session with the template UUID and hash", func(t *testing.T) {
+		// given
+		p := project.Project{
+			UUID: "1234",
+			Name: "foo",
+			Template: template.Template{
+				Root:    "/code/foo",
+				Windows: []window.Window{{Name: "shell"}},
+			},
+		}
+
+		m := multiplexer.TmuxMultiplexer{}
+
+		// when
+		commands, err := m.Plan(p)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{
+			{"tmux", "new-session", "-d", "-s", "foo", "-c", "/code/foo", "-n", "shell"},
+			{
+				"tmux", "set-option", "-t", "=foo:", "@thop_name", "foo",
+				";", "set-option", "-t", "=foo:", "@thop_uuid", "1234",
+				";", "set-option", "-t", "=foo:", "@thop_hash", p.Template.Hash(),
+			},
+		}, commands)
+	})
+}
+
+func Test_StartProject(t *testing.T) {
+	t.Run("assembles session without attaching to it", func(t *testing.T) {
+		// given
+		p := project.Project{
+			UUID: "foo",
+			Name: "foo",
+			Template: template.Template{
+				Root:    "/home/test",
+				Windows: []window.Window{{Name: "main"}},
+			},
+		}
+
+		mockClient := new(MockTmuxClient)
+		mockClient.On("HasSession", multiplexer.SessionName("foo")).Return(false, nil).Once()
+		mockClient.On("NewSession", multiplexer.SessionName("foo"), template.Root("/home/test"), window.Name("main"), window.Root(""), template.Env(nil)).Return(nil).Once()
+		mockClient.On("SetSessionOptions", multiplexer.SessionName("foo"), []multiplexer.Option{
+			{Name: "@thop_name", Value: "foo"},
+			{Name: "@thop_uuid", Value: "foo"},
+			{Name: "@thop_hash", Value: p.Template.Hash()},
+		}).Return(nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient}
+
+		// when
+		err := m.StartProject(p)
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("does nothing if session already exists", func(t *testing.T) {
+		// given
+		p := project.Project{Name: "foo", Type: project.TypeTmuxSession}
+
+		mockClient := new(MockTmuxClient)
+		mockClient.On("HasSession", multiplexer.SessionName("foo")).Return(true, nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient}
+
+		// when
+		err := m.StartProject(p)
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func Test_RebuildProject(t *testing.T) {
+	p := project.Project{
+		UUID: "foo",
+		Name: "foo",
+		Template: template.Template{
+			Root:    "/home/test",
+			Windows: []window.Window{{Name: "main"}},
+		},
+	}
+
+	expectAssembly := func(mockClient *MockTmuxClient, sessionName multiplexer.SessionName) {
+		mockClient.On("NewSession", sessionName, template.Root("/home/test"), window.Name("main"), window.Root(""), template.Env(nil)).Return(nil).Once()
+		mockClient.On("SetSessionOptions", sessionName, []multiplexer.Option{
+			{Name: "@thop_name", Value: "foo"},
+			{Name: "@thop_uuid", Value: "foo"},
+			{Name: "@thop_hash", Value: p.Template.Hash()},
+		}).Return(nil).Once()
+	}
+
+	t.Run("builds new session next to the running one and moves the client over", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("HasSession", multiplexer.SessionName("foo")).Return(true, nil).Once()
+		expectAssembly(mockClient, "foo_thop_rebuild")
+		switched := mockClient.On("SwitchSession", multiplexer.SessionName("foo_thop_rebuild")).Return(nil).Once()
+		killed := mockClient.On("KillSession", multiplexer.SessionName("foo")).Return(nil).Once().NotBefore(switched)
+		mockClient.On("RenameSession", multiplexer.SessionName("foo_thop_rebuild"), multiplexer.SessionName("foo")).Return(nil).Once().NotBefore(killed)
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: "/tmp/tmux-1000/default,1,0"}
+
+		// when
+		err := m.RebuildProject(p)
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("attaches to rebuilt session outside of tmux", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("HasSession", multiplexer.SessionName("foo")).Return(true, nil).Once()
+		expectAssembly(mockClient, "foo_thop_rebuild")
+		mockClient.On("KillSession", multiplexer.SessionName("foo")).Return(nil).Once()
+		renamed := mockClient.On("RenameSession", multiplexer.SessionName("foo_thop_rebuild"), multiplexer.SessionName("foo")).Return(nil).Once()
+		mockClient.On("AttachSession", multiplexer.SessionName("foo")).Return(nil).Once().NotBefore(renamed)
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient}
+
+		// when
+		err := m.RebuildProject(p)
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("attaches to rebuilt session on another server instead of switching to it", func(t *testing.T) {
+		// given
+		pinned := p
+		pinned.Template.Socket = "work"
+
+		defaultClient := new(MockTmuxClient)
+		workClient := new(MockTmuxClient)
+		defaultClient.On("OnSocket", multiplexer.Socket("work")).Return(workClient).Once()
+		workClient.On("HasSession", multiplexer.SessionName("foo")).Return(true, nil).Once()
+		workClient.On("NewSession", multiplexer.SessionName("foo_thop_rebuild"), mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+		workClient.On("SetSessionOptions", multiplexer.SessionName("foo_thop_rebuild"), mock.Anything).Return(nil).Once()
+		workClient.On("KillSession", multiplexer.SessionName("foo")).Return(nil).Once()
+		renamed := workClient.On("RenameSession", multiplexer.SessionName("foo_thop_rebuild"), multiplexer.SessionName("foo")).Return(nil).Once()
+		workClient.On("AttachSession", multiplexer.SessionName("foo")).Return(nil).Once().NotBefore(renamed)
+
+		m := multiplexer.TmuxMultiplexer{Client: defaultClient, ActiveTmuxSession: "/tmp/tmux-1000/default,1,0"}
+
+		// when
+		err := m.RebuildProject(pinned)
+
+		// then
+		assert.Nil(t, err)
+		workClient.AssertNotCalled(t, "SwitchSession", mock.Anything)
+		workClient.AssertExpectations(t)
+	})
+
+	t.Run("builds session when it's not running", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("HasSession", multiplexer.SessionName("foo")).Return(false, nil).Twice()
+		expectAssembly(mockClient, "foo")
+		mockClient.On("AttachSession", multiplexer.SessionName("foo")).Return(nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient}
+
+		// when
+		err := m.RebuildProject(p)
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("keeps running session when assembly fails", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("HasSession", multiplexer.SessionName("foo")).Return(true, nil).Once()
+		mockClient.On("NewSession", multiplexer.SessionName("foo_thop_rebuild"), mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(multiplexer.ErrFailedToCreateSession.WithMsg("boom")).Once()
+		mockClient.On("KillSession", multiplexer.SessionName("foo_thop_rebuild")).Return(nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient}
+
+		// when
+		err := m.RebuildProject(p)
+
+		// then
+		assert.True(t, multiplexer.ErrFailedToCreateSession.Equal(err))
+		mockClient.AssertNotCalled(t, "KillSession", multiplexer.SessionName("foo"))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("discards rebuilt session when client can't be moved over", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("HasSession", multiplexer.SessionName("foo")).Return(true, nil).Once()
+		expectAssembly(mockClient, "foo_thop_rebuild")
+		mockClient.On("SwitchSession", multiplexer.SessionName("foo_thop_rebuild")).Return(multiplexer.ErrFailedToSwitchSession.WithMsg("boom")).Once()
+		mockClient.On("KillSession", multiplexer.SessionName("foo_thop_rebuild")).Return(nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: "/tmp/tmux-1000/default,1,0"}
+
+		// when
+		err := m.RebuildProject(p)
+
+		// then
+		assert.True(t, multiplexer.ErrFailedToSwitchSession.Equal(err))
+		mockClient.AssertNotCalled(t, "KillSession", multiplexer.SessionName("foo"))
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("moves client back and discards rebuilt session when original can't be killed", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("HasSession", multiplexer.SessionName("foo")).Return(true, nil).Once()
+		expectAssembly(mockClient, "foo_thop_rebuild")
+		mockClient.On("SwitchSession", multiplexer.SessionName("foo_thop_rebuild")).Return(nil).Once()
+		mockClient.On("KillSession", multiplexer.SessionName("foo")).Return(multiplexer.ErrFailedToKillSession.WithMsg("boom")).Once()
+		back := mockClient.On("SwitchSession", multiplexer.SessionName("foo")).Return(nil).Once()
+		mockClient.On("KillSession", multiplexer.SessionName("foo_thop_rebuild")).Return(nil).Once().NotBefore(back)
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: "/tmp/tmux-1000/default,1,0"}
+
+		// when
+		err := m.RebuildProject(p)
+
+		// then
+		assert.True(t, multiplexer.ErrFailedToKillSession.Equal(err))
+		mockClient.AssertNotCalled(t, "RenameSession", mock.Anything, mock.Anything)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("returns error for session without a template", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		m := multiplexer.TmuxMultiplexer{Client: mockClient}
+
+		// when
+		err := m.RebuildProject(project.Project{Name: "foo", Type: project.TypeTmuxSession})
+
+		// then
+		assert.True(t, multiplexer.ErrTriedToBuildFromActiveSession.Equal(err))
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func Test_UpdateProject(t *testing.T) {
+	p := project.Project{
+		Name: "foo",
+		Template: template.Template{
+			Root:     "/home/test",
+			Commands: []command.Command{"nvm use"},
+			Windows: []window.Window{
+				{Name: "editor", Panes: []pane.Pane{{Commands: []command.Command{"vim"}}, {Root: "/tmp", Commands: []command.Command{"npm start"}}}},
+				{Name: "shell"},
+				{Name: "logs", Root: "/var/log", Commands: []command.Command{"tail -f app.log"}},
+			},
+		},
+	}
+
+	t.Run("adds missing windows and panes without touching existing ones", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("HasSession", multiplexer.SessionName("foo")).Return(true, nil).Twice()
+		mockClient.On("ListWindows", multiplexer.SessionName("foo")).Return([]multiplexer.SessionWindow{
+			{Name: "editor", Panes: 1},
+			{Name: "shell", Panes: 1},
+		}, nil).Once()
+		mockClient.On("SplitWindow", multiplexer.SessionName("foo"), window.Name("editor"), pane.Root("/tmp")).Return(nil).Once()
+		mockClient.On("SendKeys", multiplexer.SessionName("foo"), window.Name("editor"), command.Command("nvm use")).Return(nil).Once()
+		mockClient.On("SendKeys", multiplexer.SessionName("foo"), window.Name("editor"), command.Command("npm start")).Return(nil).Once()
+		mockClient.On("NewWindow", multiplexer.SessionName("foo"), template.Root("/home/test"), window.Name("logs"), window.Root("/var/log")).Return(nil).Once()
+		mockClient.On("SendKeys", multiplexer.SessionName("foo"), window.Name("logs"), command.Command("nvm use")).Return(nil).Once()
+		mockClient.On("SendKeys", multiplexer.SessionName("foo"), window.Name("logs"), command.Command("tail -f app.log")).Return(nil).Once()
+		mockClient.On("SwitchSession", multiplexer.SessionName("foo")).Return(nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: "/tmp/tmux-1000/default,1,0"}
+
+		// when
+		err := m.UpdateProject(p)
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("builds session when it's not running", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("HasSession", multiplexer.SessionName("foo")).Return(false, nil).Twice()
+		mockClient.On("NewSession", multiplexer.SessionName("foo"), template.Root("/home/test"), window.Name("editor"), window.Root(""), template.Env(nil)).Return(nil).Once()
+		mockClient.On("NewWindow", multiplexer.SessionName("foo"), mock.Anything, mock.Anything, mock.Anything).Return(nil).Twice()
+		mockClient.On("SetSessionOptions", multiplexer.SessionName("foo"), mock.Anything).Return(nil).Once()
+		mockClient.On("SplitWindow", multiplexer.SessionName("foo"), mock.Anything, mock.Anything).Return(nil).Once()
+		mockClient.On("SendKeys", multiplexer.SessionName("foo"), mock.Anything, mock.Anything).Return(nil)
+		mockClient.On("AttachSession", multiplexer.SessionName("foo")).Return(nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient}
+
+		// when
+		err := m.UpdateProject(p)
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertNotCalled(t, "ListWindows", mock.Anything)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func Test_Multiplexer_KillSession(t *testing.T) {
+	active := "/tmp/tmux-1000/default,1,0"
+
+	t.Run("kills session the client is not in", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("DisplayMessage", "#S").Return("bar", nil).Once()
+		mockClient.On("KillSession", multiplexer.SessionName("foo")).Return(nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: active}
+
+		// when
+		err := m.KillSession(project.Project{Name: "foo", Type: project.TypeTmuxSession})
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertNotCalled(t, "SwitchSession", mock.Anything)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("kills renamed session by its tmux name", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("DisplayMessage", "#S").Return("bar", nil).Once()
+		mockClient.On("KillSession", multiplexer.SessionName("renamed")).Return(nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: active}
+
+		// when
+		err := m.KillSession(project.Project{Name: "foo", Type: project.TypeTmuxSession, Session: &project.Session{Name: "renamed"}})
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("switches to previous session before killing the current one", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("DisplayMessage", "#S").Return("foo", nil).Once()
+		mockClient.On("DisplayMessage", "#{client_last_session}").Return("bar", nil).Once()
+		mockClient.On("HasSession", multiplexer.SessionName("bar")).Return(true, nil).Once()
+		switched := mockClient.On("SwitchSession", multiplexer.SessionName("bar")).Return(nil).Once()
+		mockClient.On("KillSession", multiplexer.SessionName("foo")).Return(nil).Once().NotBefore(switched)
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: active}
+
+		// when
+		err := m.KillSession(project.Project{Name: "foo", Type: project.TypeTmuxSession})
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("switches to any other session when previous one is gone", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("DisplayMessage", "#S").Return("foo", nil).Once()
+		mockClient.On("DisplayMessage", "#{client_last_session}").Return("bar", nil).Once()
+		mockClient.On("HasSession", multiplexer.SessionName("bar")).Return(false, nil).Once()
+		mockClient.On("ListSessions").Return([]multiplexer.Session{{Name: "foo"}, {Name: "baz"}}, nil).Once()
+		switched := mockClient.On("SwitchSession", multiplexer.SessionName("baz")).Return(nil).Once()
+		mockClient.On("KillSession", multiplexer.SessionName("foo")).Return(nil).Once().NotBefore(switched)
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: active}
+
+		// when
+		err := m.KillSession(project.Project{Name: "foo", Type: project.TypeTmuxSession})
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("detaches from the last session before killing it", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("DisplayMessage", "#S").Return("foo", nil).Once()
+		mockClient.On("DisplayMessage", "#{client_last_session}").Return("", nil).Once()
+		mockClient.On("ListSessions").Return([]multiplexer.Session{{Name: "foo"}}, nil).Once()
+		detached := mockClient.On("DetachClient").Return(nil).Once()
+		mockClient.On("KillSession", multiplexer.SessionName("foo")).Return(nil).Once().NotBefore(detached)
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: active}
+
+		// when
+		err := m.KillSession(project.Project{Name: "foo", Type: project.TypeTmuxSession})
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertNotCalled(t, "SwitchSession", mock.Anything)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("detaches instead of switching when configured so", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("DisplayMessage", "#S").Return("foo", nil).Once()
+		detached := mockClient.On("DetachClient").Return(nil).Once()
+		mockClient.On("KillSession", multiplexer.SessionName("foo")).Return(nil).Once().NotBefore(detached)
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: active, KillFallback: config.FallbackDetach}
+
+		// when
+		err := m.KillSession(project.Project{Name: "foo", Type: project.TypeTmuxSession})
+
+		// then
+		assert.Nil(t, err)
+		mockClient.AssertNotCalled(t, "SwitchSession", mock.Anything)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func Test_PreviousSession(t *testing.T) {
+	t.Run("returns last session of the client", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("DisplayMessage", "#{client_last_session}").Return("foo", nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: "/tmp/tmux-1000/default,1,0"}
+
+		// when
+		previous, err := m.PreviousSession()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, multiplexer.SessionName("foo"), previous)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("returns empty name outside of tmux", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		m := multiplexer.TmuxMultiplexer{Client: mockClient}
+
+		// when
+		previous, err := m.PreviousSession()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, multiplexer.SessionName(""), previous)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func Test_CurrentSession(t *testing.T) {
+	t.Run("returns current session of the client", func(t *testing.T) {
+		// given
+		mockClient := new(MockTmuxClient)
+		mockClient.On("DisplayMessage", "#S").Return("bar", nil).Once()
+
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, ActiveTmuxSession: "/tmp/tmux-1000/default,1,0"}
+
+		// when
+		current, err := m.CurrentSession()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, multiplexer.SessionName("bar"), current)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func Test_Plan(t *testing.T) {
+	t.Run("returns commands used to assemble the session without running them", func(t *testing.T) {
+		// given
+		p := project.Project{
+			Name: "foo",
+			Template: template.Template{
+				Root:     "/home/test",
+				Commands: []command.Command{"echo hello"},
+				Windows: []window.Window{
+					{Name: "main", Root: "/project"},
+					{Name: "logs", Commands: []command.Command{"tail -f log.txt"}},
+				},
+			},
+		}
+
+		mockClient := new(MockTmuxClient)
+		m := multiplexer.TmuxMultiplexer{Client: mockClient}
+
+		// when
+		commands, err := m.Plan(p)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{
+			{"tmux", "new-session", "-d", "-s", "foo", "-c", "/home/test", "-n", "main", "cd /project && exec $SHELL"},
+			{"tmux", "set-option", "-t", "=foo:", "@thop_name", "foo"},
+			{"tmux", "send-keys", "-t", "foo:main", "echo hello", "C-m"},
+			{"tmux", "new-window", "-d", "-t", "foo", "-n", "logs", "-c", "/home/test"},
+			{"tmux", "send-keys", "-t", "foo:logs", "echo hello", "C-m"},
+			{"tmux", "send-keys", "-t", "foo:logs", "tail -f log.txt", "C-m"},
+		}, commands)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("targets the server of template socket", func(t *testing.T) {
+		// given
+		p := project.Project{
+			Name: "foo",
+			Template: template.Template{
+				Root:    "/home/test",
+				Socket:  "/tmp/tmux-work",
+				Windows: []window.Window{{Name: "main"}},
+			},
+		}
+
+		mockClient := new(MockTmuxClient)
+		m := multiplexer.TmuxMultiplexer{Client: mockClient, Socket: "work"}
+
+		// when
+		commands, err := m.Plan(p)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{
+			{"tmux", "-S", "/tmp/tmux-work", "new-session", "-d", "-s", "foo", "-c", "/home/test", "-n", "main"},
+			{"tmux", "-S", "/tmp/tmux-work", "set-option", "-t", "=foo:", "@thop_name", "foo"},
+		}, commands)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("splits panes, applies layout and sends commands to every pane", func(t *testing.T) {
+		// given
+		p := project.Project{
+			Name: "foo",
+			Template: template.Template{
+				Root: "/home/test",
+				Env:  template.Env{"FOO": "bar"},
+				Windows: []window.Window{
+					{
+						Name:     "main",
+						Root:     "/project",
+						Layout:   "main-vertical",
+						Commands: []command.Command{"nvm use"},
+						Panes: []pane.Pane{
+							{Root: "/project/web", Commands: []command.Command{"vim"}},
+							{Commands: []command.Command{"npm start"}},
+							{Root: "/tmp"},
+						},
+					},
+				},
+			},
+		}
+
+		m := multiplexer.TmuxMultiplexer{}
+
+		// when
+		commands, err := m.Plan(p)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{
+			{"tmux", "new-session", "-d", "-s", "foo", "-c", "/home/test", "-n", "main", "-e", "FOO=bar", "cd /project/web && exec $SHELL"},
+			{"tmux", "set-option", "-t", "=foo:", "@thop_name", "foo"},
+			{"tmux", "send-keys", "-t", "foo:main", "nvm use", "C-m"},
+			{"tmux", "send-keys", "-t", "foo:main", "vim", "C-m"},
+			{"tmux", "split-window", "-t", "foo:main", "-c", "/project"},
+			{"tmux", "select-layout", "-t", "foo:main", "main-vertical"},
+			{"tmux", "send-keys", "-t", "foo:main", "nvm use", "C-m"},
+			{"tmux", "send-keys", "-t", "foo:main", "npm start", "C-m"},
+			{"tmux", "split-window", "-t", "foo:main", "-c", "/tmp"},
+			{"tmux", "select-layout", "-t", "foo:main", "main-vertical"},
+			{"tmux", "send-keys", "-t", "foo:main", "nvm use", "C-m"},
+		}, commands)
+	})
+
+	t.Run("returns error for invalid template", func(t *testing.T) {
+		// given
+		m := multiplexer.TmuxMultiplexer{}
+
+		// when
+		_, err := m.Plan(project.Project{Name: "foo", Template: template.Template{Root: "/home/test"}})
+
+		// then
+		assert.True(t, multiplexer.ErrInvalidTemplateArgs.Equal(err))
+	})
 }
@@ -0,0 +1,141 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+	"thop/internal/output"
+	"thop/internal/service"
+
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var activity = time.Unix(1792428202, 0).UTC()
+
+var items = []service.ListItem{
+	{UUID: "1234", Name: "foo", SessionName: "foo", Root: "/home/test/foo", Windows: 2, Running: true, Template: true},
+	{Name: "bar", SessionName: "bar", Running: true, Windows: 1, Attached: true, Activity: &activity, SessionPath: "/tmp", Managed: true},
+}
+
+func Test_WriteList(t *testing.T) {
+	t.Run("writes table", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+		lastActive := time.Now().Add(-3 * time.Hour)
+		running := items[1]
+		running.Activity = &lastActive
+
+		// when
+		err := output.WriteList(&buf, output.FormatTable, []service.ListItem{items[0], running})
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, ""+
+			"NAME  SESSION  RUNNING  ATTACHED  IDLE  WINDOWS  ROOT            UUID\n"+
+			"foo   foo      yes      no        -     2        /home/test/foo  1234\n"+
+			"bar   bar      yes      yes       3h    1        -               -\n", buf.String())
+	})
+
+	t.Run("writes socket column when sessions of another server are listed", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+		lastActive := time.Now().Add(-3 * time.Hour)
+		running := items[1]
+		running.Activity = &lastActive
+		running.Socket = "work"
+
+		// when
+		err := output.WriteList(&buf, output.FormatTable, []service.ListItem{items[0], running})
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, ""+
+			"NAME  SESSION  RUNNING  ATTACHED  IDLE  WINDOWS  ROOT            UUID  SOCKET\n"+
+			"foo   foo      yes      no        -     2        /home/test/foo  1234  -\n"+
+			"bar   bar      yes      yes       3h    1        -               -     work\n", buf.String())
+	})
+
+	t.Run("writes error column when some template is broken", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+		broken := items[0]
+		broken.Running = false
+		broken.Error = "template foo extends nope, which doesn't exist"
+
+		// when
+		err := output.WriteList(&buf, output.FormatTable, []service.ListItem{broken})
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, ""+
+			"NAME  SESSION  RUNNING  ATTACHED  IDLE  WINDOWS  ROOT            UUID  ERROR\n"+
+			"foo   foo      no       no        -     2        /home/test/foo  1234  template foo extends nope, which doesn't exist\n", buf.String())
+	})
+
+	t.Run("writes tsv", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+
+		// when
+		err := output.WriteList(&buf, output.FormatTSV, items)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, ""+
+			"1234\tfoo\tfoo\ttrue\t2\t/home/test/foo\tfalse\t0\t\tfalse\tfalse\t\t\n"+
+			"\tbar\tbar\ttrue\t1\t\ttrue\t1792428202\t/tmp\ttrue\tfalse\t\t\n", buf.String())
+	})
+
+	t.Run("writes json", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+
+		// when
+		err := output.WriteList(&buf, output.FormatJSON, items[:1])
+
+		// then
+		assert.Nil(t, err)
+		assert.JSONEq(t, `[{"uuid":"1234","name":"foo","session_name":"foo","root":"/home/test/foo","windows":2,"running":true,"template":true,"attached":false,"managed":false,"stale":false}]`, buf.String())
+	})
+
+	t.Run("writes empty json array when there are no items", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+
+		// when
+		err := output.WriteList(&buf, output.FormatJSON, nil)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "[]\n", buf.String())
+	})
+
+	t.Run("writes go template per item", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+
+		// when
+		err := output.WriteList(&buf, "go-template={{.Name}}:{{.Running}}", items)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "foo:true\nbar:true\n", buf.String())
+	})
+
+	t.Run("returns error for invalid go template", func(t *testing.T) {
+		// when
+		err := output.WriteList(&bytes.Buffer{}, "go-template={{.Name", items)
+
+		// then
+		assert.True(t, output.ErrInvalidTemplate.Equal(err))
+	})
+
+	t.Run("returns error for unknown format", func(t *testing.T) {
+		// when
+		err := output.WriteList(&bytes.Buffer{}, "xml", items)
+
+		// then
+		assert.True(t, output.ErrUnknownFormat.Equal(err))
+	})
+}
@@ -0,0 +1,78 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+	"thop/internal/output"
+	"thop/internal/service"
+	"thop/internal/types"
+	"thop/internal/types/project"
+	"thop/internal/types/template"
+	"thop/internal/types/window"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var plan = service.ProjectPlan{
+	Project: project.Project{
+		UUID:    "1234",
+		Name:    "foo",
+		Version: types.V1,
+		Template: template.Template{
+			Root:    "/home/test",
+			Windows: []window.Window{{Name: "main"}},
+		},
+	},
+	SessionName: "foo",
+	Commands: [][]string{
+		{"tmux", "new-session", "-d", "-s", "foo", "-c", "/home/test", "-n", "main"},
+		{"tmux", "send-keys", "-t", "foo:main", "echo 'hi there'", "C-m"},
+	},
+}
+
+func Test_WritePlan(t *testing.T) {
+	t.Run("writes yaml with commands as comments", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+
+		// when
+		err := output.WritePlan(&buf, output.FormatYAML, plan)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, ""+
+			"# uuid: 1234\n"+
+			"# session: foo\n"+
+			"name: foo\n"+
+			"version: 1\n"+
+			"template:\n"+
+			"  root: /home/test\n"+
+			"  windows:\n"+
+			"  - name: main\n"+
+			"\n"+
+			"# tmux commands:\n"+
+			"# tmux new-session -d -s foo -c /home/test -n main\n"+
+			"# tmux send-keys -t foo:main 'echo '\\''hi there'\\''' C-m\n", buf.String())
+	})
+
+	t.Run("writes json", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+
+		// when
+		err := output.WritePlan(&buf, output.FormatJSON, plan)
+
+		// then
+		assert.Nil(t, err)
+		assert.Contains(t, buf.String(), `"session_name": "foo"`)
+		assert.Contains(t, buf.String(), `"uuid": "1234"`)
+	})
+
+	t.Run("returns error for unknown format", func(t *testing.T) {
+		// when
+		err := output.WritePlan(&bytes.Buffer{}, output.FormatTSV, plan)
+
+		// then
+		assert.True(t, output.ErrUnknownFormat.Equal(err))
+	})
+}
@@ -0,0 +1,90 @@
+package output_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"thop/internal/output"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WriteScript(t *testing.T) {
+	t.Run("writes tmux commands after existing session check", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+
+		// when
+		err := output.WriteScript(&buf, plan)
+
+		// then
+		assert.Nil(t, err)
+		script := buf.String()
+		assert.True(t, strings.HasPrefix(script, "#!/usr/bin/env bash\n"))
+		assert.Contains(t, script, "session=foo\n")
+
+		check := strings.Index(script, "if tmux has-session -t \"=$session\" 2>/dev/null; then\n  attach\n  exit 0\nfi\n")
+		create := strings.Index(script, "tmux new-session -d -s foo -c /home/test -n main\n")
+		keys := strings.Index(script, "tmux send-keys -t foo:main 'echo '\\''hi there'\\''' C-m\n")
+		assert.NotEqual(t, -1, check)
+		assert.Greater(t, create, check)
+		assert.Greater(t, keys, create)
+		assert.True(t, strings.HasSuffix(script, "\nattach\n"))
+	})
+
+	t.Run("quotes session name", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+		quoted := plan
+		quoted.SessionName = "my project"
+
+		// when
+		err := output.WriteScript(&buf, quoted)
+
+		// then
+		assert.Nil(t, err)
+		assert.Contains(t, buf.String(), "session='my project'\n")
+	})
+
+	t.Run("checks and attaches to the session on the server of the socket", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+		pinned := plan
+		pinned.Commands = [][]string{
+			{"tmux", "-S", "/tmp/my sock", "new-session", "-d", "-s", "foo", "-c", "/home/test", "-n", "main"},
+		}
+
+		// when
+		err := output.WriteScript(&buf, pinned)
+
+		// then
+		assert.Nil(t, err)
+		script := buf.String()
+		assert.Contains(t, script, "tmux=(tmux -S '/tmp/my sock')\n")
+		assert.Contains(t, script, "if \"${tmux[@]}\" has-session -t \"=$session\" 2>/dev/null; then\n")
+		assert.Contains(t, script, "    \"${tmux[@]}\" switch-client -t \"=$session\"\n")
+		assert.Contains(t, script, "    \"${tmux[@]}\" attach-session -t \"=$session\"\n")
+		assert.Contains(t, script, "tmux -S '/tmp/my sock' new-session -d -s foo -c /home/test -n main\n")
+	})
+
+	t.Run("leaves home and environment variables in roots to the shell running the script", func(t *testing.T) {
+		// given
+		var buf bytes.Buffer
+		portable := plan
+		portable.Commands = [][]string{
+			{"tmux", "new-session", "-d", "-s", "foo", "-c", "~/x", "-n", "main"},
+			{"tmux", "new-window", "-d", "-t", "foo", "-n", "src", "-c", "$PROJECTS/foo src"},
+			{"tmux", "split-window", "-t", "foo:src", "-c", "${PROJECTS}/$(rm -rf ~)/`id`/$1"},
+		}
+
+		// when
+		err := output.WriteScript(&buf, portable)
+
+		// then
+		assert.Nil(t, err)
+		script := buf.String()
+		assert.Contains(t, script, "tmux new-session -d -s foo -c \"$HOME/x\" -n main\n")
+		assert.Contains(t, script, "tmux new-window -d -t foo -n src -c \"$PROJECTS/foo src\"\n")
+		assert.Contains(t, script, "tmux split-window -t foo:src -c \"${PROJECTS}/\\$(rm -rf ~)/\\`id\\`/\\$1\"\n")
+	})
+}
@@ -0,0 +1,42 @@
+package prompt_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"thop/internal/prompt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Confirm(t *testing.T) {
+	t.Run("accepts yes answers", func(t *testing.T) {
+		for _, answer := range []string{"y\n", "Yes\n", " YES "} {
+			// given
+			var out bytes.Buffer
+			p := prompt.TerminalPrompter{In: strings.NewReader(answer), Out: &out}
+
+			// when
+			confirmed, err := p.Confirm("Continue?")
+
+			// then
+			assert.Nil(t, err)
+			assert.True(t, confirmed, answer)
+			assert.Equal(t, "Continue? [y/N] ", out.String())
+		}
+	})
+
+	t.Run("takes anything else as no", func(t *testing.T) {
+		for _, answer := range []string{"\n", "n\n", "sure\n", ""} {
+			// given
+			p := prompt.TerminalPrompter{In: strings.NewReader(answer), Out: &bytes.Buffer{}}
+
+			// when
+			confirmed, err := p.Confirm("Continue?")
+
+			// then
+			assert.Nil(t, err)
+			assert.False(t, confirmed, answer)
+		}
+	})
+}
@@ -3,11 +3,16 @@ package selector_test
 import (
 	"bytes"
 	"errors"
+	"os"
 	"os/exec"
+	"strings"
 	"testing"
+	"thop/internal/history"
 	"thop/internal/selector"
 	"thop/internal/types/project"
+	"thop/internal/types/template"
 	"thop/test"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -47,7 +52,7 @@ func Test_SelectFrom(t *testing.T) {
 
 		stdin := cmdToExec.Stdin.(*bytes.Buffer)
 		// fzf sort order is in reverse
-		assert.Equal(t, "foo\nBaz\nbar\n(Active) foo\n", stdin.String(), "stdin should be sorted")
+		assert.Equal(t, "foo\nBaz\nbar\n(Session) foo\n", stdin.String(), "stdin should be sorted")
 	})
 
 	t.Run("select maps 130 exit code to ErrSelectorCancelled", func(t *testing.T) {
@@ -71,6 +76,32 @@ func Test_SelectFrom(t *testing.T) {
 		execMock.AssertExpectations(t)
 	})
 
+	t.Run("marks templates with running session as active", func(t *testing.T) {
+		// given
+		var cmdToExec *exec.Cmd
+
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Run(func(args mock.Arguments) {
+			cmdToExec = args.Get(0).(*exec.Cmd)
+		}).Return("(Active) foo\n", 0, nil).Once()
+
+		projects := []project.Project{
+			{Name: "bar", Type: project.TypeTemplate},
+			{Name: "foo", Type: project.TypeTemplate, Running: true},
+			{Name: "baz", Type: project.TypeTmuxSession},
+		}
+
+		s := selector.FzfProjectSelector{E: execMock}
+
+		// when
+		selected, err := s.SelectFrom(projects, "prompt > ")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, &projects[1], selected)
+		assert.Equal(t, "bar\n(Active) foo\n(Session) baz\n", cmdToExec.Stdin.(*bytes.Buffer).String())
+	})
+
 	t.Run("select propagates errors", func(t *testing.T) {
 		// given
 		execMock := new(test.MockExecutor)
@@ -93,3 +124,307 @@ func Test_SelectFrom(t *testing.T) {
 		execMock.AssertExpectations(t)
 	})
 }
+
+func Test_SelectMany(t *testing.T) {
+	t.Run("selects multiple items", func(t *testing.T) {
+		// given
+		prompt := "foo prompt > "
+		args := []string{"fzf", "--multi", "--prompt", prompt}
+		var cmdToExec *exec.Cmd
+
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Run(func(args mock.Arguments) {
+			cmdToExec = args.Get(0).(*exec.Cmd)
+		}).Return("bar\n(Session) foo\n", 0, nil).Once()
+
+		projects := []project.Project{
+			{Name: "foo", Type: project.TypeTemplate},
+			{Name: "foo", Type: project.TypeTmuxSession},
+			{Name: "bar", Type: project.TypeTemplate},
+		}
+
+		s := selector.FzfProjectSelector{E: execMock}
+
+		// when
+		selected, err := s.SelectMany(projects, prompt)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []project.Project{projects[2], projects[1]}, selected)
+		assert.Equal(t, args, cmdToExec.Args)
+		execMock.AssertExpectations(t)
+	})
+
+	t.Run("select maps 130 exit code to ErrSelectorCancelled", func(t *testing.T) {
+		// given
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Return("", 130, errors.New("exit code 130")).Once()
+
+		s := selector.FzfProjectSelector{E: execMock}
+		projects := []project.Project{{Name: "foo", Type: project.TypeTemplate}}
+
+		// when
+		_, err := s.SelectMany(projects, "foo prompt > ")
+
+		// then
+		assert.True(t, selector.ErrSelectorCancelled.Equal(err))
+		execMock.AssertExpectations(t)
+	})
+}
+
+func Test_SelectAction(t *testing.T) {
+	projects := []project.Project{
+		{UUID: "1234", Name: "foo", Type: project.TypeTemplate},
+		{Name: "bar", Type: project.TypeTmuxSession},
+	}
+	load := func() ([]project.Project, error) { return projects, nil }
+
+	t.Run("opens selected project on enter", func(t *testing.T) {
+		// given
+		var cmdToExec *exec.Cmd
+
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Run(func(args mock.Arguments) {
+			cmdToExec = args.Get(0).(*exec.Cmd)
+		}).Return("fo\n\ntemplate:1234\tfoo\n", 0, nil).Once()
+
+		s := selector.FzfProjectSelector{E: execMock, ReloadCommand: "thop __entries"}
+
+		// when
+		selection, err := s.SelectAction(load, "prompt > ")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, selector.Selection{Project: &projects[0], Action: selector.ActionOpen, Query: "fo"}, selection)
+		assert.Contains(t, cmdToExec.Args, "ctrl-x,ctrl-e,ctrl-d,ctrl-n")
+		assert.Contains(t, cmdToExec.Args, "ctrl-r:reload(thop __entries)")
+		assert.Equal(t, "template:1234\tfoo\nsession:bar\t(Session) bar\n", cmdToExec.Stdin.(*bytes.Buffer).String())
+		execMock.AssertExpectations(t)
+	})
+
+	t.Run("maps expected key to action", func(t *testing.T) {
+		// given
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Return("\nctrl-x\nsession:bar\t(Session) bar\n", 0, nil).Once()
+
+		s := selector.FzfProjectSelector{E: execMock}
+
+		// when
+		selection, err := s.SelectAction(load, "prompt > ")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, selector.ActionKill, selection.Action)
+		assert.Equal(t, &projects[1], selection.Project)
+	})
+
+	t.Run("allows creating new project from query without a match", func(t *testing.T) {
+		// given
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Return("baz\nctrl-n\n", 1, errors.New("exit code 1")).Once()
+
+		s := selector.FzfProjectSelector{E: execMock}
+
+		// when
+		selection, err := s.SelectAction(load, "prompt > ")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, selector.Selection{Action: selector.ActionNew, Query: "baz"}, selection)
+	})
+
+	t.Run("loads items again when selected entry was added by reload", func(t *testing.T) {
+		// given
+		calls := 0
+		reloaded := append(projects, project.Project{Name: "baz", Type: project.TypeTmuxSession})
+		load := func() ([]project.Project, error) {
+			calls++
+			if calls == 1 {
+				return projects, nil
+			}
+			return reloaded, nil
+		}
+
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Return("\n\nsession:baz\t(Session) baz\n", 0, nil).Once()
+
+		s := selector.FzfProjectSelector{E: execMock}
+
+		// when
+		selection, err := s.SelectAction(load, "prompt > ")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, &reloaded[2], selection.Project)
+	})
+}
+
+func Test_FrecencyOrder(t *testing.T) {
+	now := time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC)
+
+	t.Run("orders by frecency and pins previous project second", func(t *testing.T) {
+		// given
+		var cmdToExec *exec.Cmd
+
+		execMock := new(test.MockExecutor)
+		execMock.On("Execute", mock.Anything).Run(func(args mock.Arguments) {
+			cmdToExec = args.Get(0).(*exec.Cmd)
+		}).Return("bar\n", 0, nil).Once()
+
+		hiMock := new(test.MockHistory)
+		hiMock.On("Entries").Return([]history.Entry{
+			{UUID: "3", Name: "baz", Count: 1, LastUsed: now.Add(-time.Minute)},
+			{UUID: "4", Name: "qux", Count: 1, LastUsed: now.Add(-2 * time.Minute)},
+			{UUID: "1", Name: "foo", Count: 20, LastUsed: now.Add(-time.Hour * 2)},
+			{UUID: "2", Name: "bar", Count: 5, LastUsed: now.Add(-time.Hour * 3)},
+		}, nil).Once()
+
+		projects := []project.Project{
+			{UUID: "1", Name: "foo"},
+			{UUID: "2", Name: "bar"},
+			{UUID: "3", Name: "baz"},
+			{UUID: "4", Name: "qux"},
+			{UUID: "5", Name: "abc"},
+		}
+
+		s := selector.FzfProjectSelector{
+			E:     execMock,
+			Order: selector.FrecencyOrder{History: hiMock, Now: func() time.Time { return now }},
+		}
+
+		// when
+		_, err := s.SelectFrom(projects, "prompt > ")
+
+		// then
+		assert.Nil(t, err)
+		// foo: 40, bar: 10, baz: 4, qux: 4 (pinned second as previous), abc: no history
+		assert.Equal(t, "foo\nqux\nbar\nbaz\nabc\n", cmdToExec.Stdin.(*bytes.Buffer).String())
+		hiMock.AssertExpectations(t)
+	})
+
+	t.Run("feeds directories last, farthest from the prompt", func(t *testing.T) {
+		// given
+		hiMock := new(test.MockHistory)
+		hiMock.On("Entries").Return([]history.Entry{
+			{Name: "api", Count: 50, LastUsed: now},
+		}, nil)
+
+		projects := []project.Project{
+			{Name: "api", Type: project.TypeDirectory, Template: template.Template{Root: "/srv/api"}},
+			{UUID: "1", Name: "foo"},
+			{UUID: "2", Name: "bar", Running: true},
+			{Name: "baz", Type: project.TypeTmuxSession},
+		}
+
+		for _, order := range []selector.Ordering{
+			selector.AlphabeticalOrder{},
+			selector.FrecencyOrder{History: hiMock, Now: func() time.Time { return now }},
+		} {
+			var buf bytes.Buffer
+			s := selector.FzfProjectSelector{Order: order}
+
+			// when
+			err := s.WriteEntries(&buf, projects)
+
+			// then
+			assert.Nil(t, err)
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			assert.Len(t, lines, 4)
+			assert.Equal(t, "dir:/srv/api\t(Dir) /srv/api", lines[3], "%T", order)
+		}
+	})
+}
+
+func Test_WriteEntries(t *testing.T) {
+	t.Run("labels shared templates with their source", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{UUID: "1234", Name: "foo", Type: project.TypeTemplate},
+			{UUID: "1234", Name: "bar", Type: project.TypeTemplate, Source: "team", Running: true},
+		}
+
+		var buf bytes.Buffer
+		s := selector.FzfProjectSelector{}
+
+		// when
+		err := s.WriteEntries(&buf, projects)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "template:1234\tfoo\ntemplate:team/1234\t(Active) bar [team]\n", buf.String())
+	})
+
+	t.Run("lists directories with home shortened", func(t *testing.T) {
+		// given
+		home, _ := os.UserHomeDir()
+		projects := []project.Project{
+			{Name: "api", Type: project.TypeDirectory, Template: template.Template{Root: template.Root(home + "/code/api")}},
+			{Name: "web", Type: project.TypeDirectory, Template: template.Template{Root: "/srv/web"}},
+		}
+
+		var buf bytes.Buffer
+		s := selector.FzfProjectSelector{}
+
+		// when
+		err := s.WriteEntries(&buf, projects)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "dir:"+home+"/code/api\t(Dir) ~/code/api\ndir:/srv/web\t(Dir) /srv/web\n", buf.String())
+	})
+	t.Run("shows windows, idle time and stale template of running sessions", func(t *testing.T) {
+		// given
+		now := time.Unix(1792428202, 0)
+		projects := []project.Project{
+			{UUID: "1234", Name: "foo", Type: project.TypeTemplate, Running: true, Session: &project.Session{Windows: 3, Activity: now.Add(-3 * 24 * time.Hour), Stale: true}},
+			{Name: "bar", Type: project.TypeTmuxSession, Session: &project.Session{Windows: 1, Attached: 1, Activity: now}},
+		}
+
+		var buf bytes.Buffer
+		s := selector.FzfProjectSelector{Now: func() time.Time { return now }}
+
+		// when
+		err := s.WriteEntries(&buf, projects)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "template:1234\t(Active) foo (3 windows, idle 3d, stale)\nsession:bar\t(Session) bar (1 window, attached)\n", buf.String())
+	})
+
+	t.Run("tells apart sessions of the same name on other servers", func(t *testing.T) {
+		// given
+		now := time.Unix(1792428202, 0)
+		projects := []project.Project{
+			{Name: "bar", Type: project.TypeTmuxSession, Session: &project.Session{Windows: 1, Activity: now}},
+			{Name: "bar", Type: project.TypeTmuxSession, Session: &project.Session{Windows: 2, Activity: now, Socket: "work"}},
+		}
+
+		var buf bytes.Buffer
+		s := selector.FzfProjectSelector{Now: func() time.Time { return now }}
+
+		// when
+		err := s.WriteEntries(&buf, projects)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "session:bar\t(Session) bar (1 window, idle 0m)\nsession:bar@work\t(Session) bar (2 windows, idle 0m, on work)\n", buf.String())
+	})
+
+	t.Run("marks broken templates with the reason", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{UUID: "1234", Name: "foo", Type: project.TypeTemplate, Broken: "templates extend each other: foo -> bar -> foo"},
+		}
+
+		var buf bytes.Buffer
+		s := selector.FzfProjectSelector{}
+
+		// when
+		err := s.WriteEntries(&buf, projects)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, "template:1234\tfoo (broken: templates extend each other: foo -> bar -> foo)\n", buf.String())
+	})
+}
@@ -3,15 +3,24 @@ package service_test
 import (
 	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"testing"
 	"thop/internal/config"
+	"thop/internal/git"
+	"thop/internal/history"
+	"thop/internal/importer"
+	"thop/internal/multiplexer"
 	"thop/internal/problem"
+	"thop/internal/selector"
 	"thop/internal/service"
 	"thop/internal/storage"
+	"thop/internal/types/command"
 	"thop/internal/types/project"
 	"thop/internal/types/template"
 	"thop/internal/types/window"
 	"thop/test"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -70,7 +79,7 @@ func Test_OpenProject(t *testing.T) {
 		projects := []project.Project{{UUID: "1234", Name: "foobar"}}
 
 		slMock := new(test.MockProjectSelector)
-		slMock.On("SelectFrom", projects, mock.Anything).Return(&projects[0], nil).Once()
+		slMock.On("SelectAction", projects, mock.Anything).Return(selector.Selection{Project: &projects[0], Action: selector.ActionOpen}, nil).Once()
 
 		stMock := new(test.MockStorage)
 		stMock.On("List").Return(projects, nil).Once()
@@ -80,15 +89,19 @@ func Test_OpenProject(t *testing.T) {
 
 		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil).Once()
 
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", mock.Anything).Return(nil).Once()
+
 		svc := &service.AppService{
 			Selector:    slMock,
 			Multiplexer: muMock,
 			Storage:     stMock,
+			History:     hiMock,
 			E:           nil,
 		}
 
 		// when
-		err := svc.OpenProject("")
+		err := svc.OpenProject("", service.OpenOptions{})
 
 		// then
 		assert.Nil(t, err)
@@ -106,11 +119,14 @@ func Test_OpenProject(t *testing.T) {
 			{Name: "foobar", Type: project.TypeTmuxSession},
 			{Name: "barfoo", Type: project.TypeTmuxSession},
 		}
-		// First "session" should be filtered out
-		combined := append(projects, sessions...)
+		// First "session" should be merged into the template
+		combined := []project.Project{
+			{UUID: "1234", Name: "foobar", Running: true},
+			{Name: "barfoo", Type: project.TypeTmuxSession},
+		}
 
 		slMock := new(test.MockProjectSelector)
-		slMock.On("SelectFrom", combined, mock.Anything).Return(&combined[1], nil).Once()
+		slMock.On("SelectAction", combined, mock.Anything).Return(selector.Selection{Project: &combined[1], Action: selector.ActionOpen}, nil).Once()
 
 		stMock := new(test.MockStorage)
 		stMock.On("List").Return(projects, nil).Once()
@@ -118,16 +134,21 @@ func Test_OpenProject(t *testing.T) {
 		muMock := new(test.MockMultiplexer)
 		muMock.On("AttachProject", combined[1]).Return(nil).Once()
 		muMock.On("ListActiveSessions").Return(sessions, nil).Once()
+		muMock.On("ResolveSessionName", projects[0]).Return(multiplexer.SessionName("foobar"), nil).Once()
+
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", mock.Anything).Return(nil).Once()
 
 		svc := &service.AppService{
 			Selector:    slMock,
 			Multiplexer: muMock,
 			Storage:     stMock,
+			History:     hiMock,
 			E:           nil,
 		}
 
 		// when
-		err := svc.OpenProject("")
+		err := svc.OpenProject("", service.OpenOptions{})
 
 		// then
 		assert.Nil(t, err)
@@ -142,19 +163,25 @@ func Test_OpenProject(t *testing.T) {
 
 		stMock := new(test.MockStorage)
 		stMock.On("Find", p.Name).Return(p, nil).Once()
+		stMock.On("List").Return([]project.Project{p}, nil).Once()
 
 		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil).Once()
 		muMock.On("AttachProject", p).Return(nil).Once()
 
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", mock.Anything).Return(nil).Once()
+
 		svc := &service.AppService{
 			Selector:    nil,
 			Multiplexer: muMock,
 			Storage:     stMock,
+			History:     hiMock,
 			E:           nil,
 		}
 
 		// when
-		err := svc.OpenProject(p.Name)
+		err := svc.OpenProject(p.Name, service.OpenOptions{})
 
 		// then
 		assert.Nil(t, err)
@@ -177,7 +204,7 @@ func Test_OpenProject(t *testing.T) {
 		}
 
 		// when
-		err := svc.OpenProject("foobar")
+		err := svc.OpenProject("foobar", service.OpenOptions{})
 
 		// then
 		assert.Equal(t, expected, err)
@@ -189,7 +216,7 @@ func Test_OpenProject(t *testing.T) {
 		expected := errors.New("expected error")
 
 		slMock := new(test.MockProjectSelector)
-		slMock.On("SelectFrom", mock.Anything, mock.Anything).Return("", nil).Once()
+		slMock.On("SelectAction", mock.Anything, mock.Anything).Return(selector.Selection{}, nil).Once()
 
 		stMock := new(test.MockStorage)
 		stMock.On("List").Return([]project.Project{}, expected).Once()
@@ -202,7 +229,7 @@ func Test_OpenProject(t *testing.T) {
 		}
 
 		// when
-		err := svc.OpenProject("")
+		err := svc.OpenProject("", service.OpenOptions{})
 
 		// then
 		assert.Equal(t, expected, err)
@@ -215,7 +242,7 @@ func Test_OpenProject(t *testing.T) {
 		listReturn := []project.Project{{UUID: "1234", Name: "foobar"}}
 
 		slMock := new(test.MockProjectSelector)
-		slMock.On("SelectFrom", mock.Anything, mock.Anything).Return(&project.Project{}, expected).Once()
+		slMock.On("SelectAction", mock.Anything, mock.Anything).Return(selector.Selection{}, expected).Once()
 
 		stMock := new(test.MockStorage)
 		stMock.On("List").Return(listReturn, nil).Once()
@@ -231,7 +258,7 @@ func Test_OpenProject(t *testing.T) {
 		}
 
 		// when
-		err := svc.OpenProject("")
+		err := svc.OpenProject("", service.OpenOptions{})
 
 		// then
 		assert.Equal(t, expected, err)
@@ -258,21 +285,242 @@ func Test_OpenProject(t *testing.T) {
 		muMock.On("AttachProject", combined[1]).Return(nil).Once()
 		muMock.On("ListActiveSessions").Return(sessions, nil).Once()
 
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", mock.Anything).Return(nil).Once()
+
 		svc := &service.AppService{
 			Selector:    nil,
 			Multiplexer: muMock,
 			Storage:     stMock,
+			History:     hiMock,
 			E:           nil,
 		}
 
 		// when
-		err := svc.OpenProject("foobar")
+		err := svc.OpenProject("foobar", service.OpenOptions{})
 
 		// then
 		assert.Nil(t, err)
 		stMock.AssertExpectations(t)
 		muMock.AssertExpectations(t)
 	})
+
+	t.Run("runs selector actions and returns to selector until project is opened", func(t *testing.T) {
+		// given
+		projects := []project.Project{{UUID: "1234", Name: "foobar"}}
+		sessions := []project.Project{{Name: "barfoo", Type: project.TypeTmuxSession}}
+		combined := append(projects, sessions...)
+
+		slMock := new(test.MockProjectSelector)
+		slMock.On("SelectAction", combined, mock.Anything).Return(selector.Selection{Project: &combined[1], Action: selector.ActionKill}, nil).Once()
+		slMock.On("SelectAction", projects, mock.Anything).Return(selector.Selection{Project: &projects[0], Action: selector.ActionDelete}, nil).Once()
+		slMock.On("SelectAction", []project.Project(nil), mock.Anything).Return(selector.Selection{}, selector.ErrSelectorCancelled.WithMsg("operation cancelled")).Once()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(projects, nil).Twice()
+		stMock.On("List").Return([]project.Project(nil), nil).Once()
+		stMock.On("Delete", project.UUID("1234")).Return(nil).Once()
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil).Once()
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil).Twice()
+		muMock.On("ResolveSessionName", projects[0]).Return(multiplexer.SessionName("foobar"), nil).Once()
+		muMock.On("KillSession", sessions[0]).Return(nil).Once()
+
+		prMock := new(test.MockPrompter)
+		prMock.On("Confirm", "Delete template foobar?").Return(true, nil).Once()
+
+		svc := &service.AppService{
+			Selector:    slMock,
+			Multiplexer: muMock,
+			Storage:     stMock,
+			Prompter:    prMock,
+		}
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{})
+
+		// then
+		assert.True(t, selector.ErrSelectorCancelled.Equal(err))
+		slMock.AssertExpectations(t)
+		stMock.AssertExpectations(t)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("does not allow deleting active sessions from selector, and keeps it open", func(t *testing.T) {
+		// given
+		sessions := []project.Project{{Name: "barfoo", Type: project.TypeTmuxSession}}
+
+		slMock := new(test.MockProjectSelector)
+		slMock.On("SelectAction", sessions, mock.Anything).Return(selector.Selection{Project: &sessions[0], Action: selector.ActionDelete}, nil).Once()
+		slMock.On("SelectAction", sessions, mock.Anything).Return(selector.Selection{}, selector.ErrSelectorCancelled.WithMsg("operation cancelled")).Once()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project(nil), nil)
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil)
+
+		svc := &service.AppService{
+			Selector:    slMock,
+			Multiplexer: muMock,
+			Storage:     stMock,
+		}
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{})
+
+		// then
+		assert.True(t, selector.ErrSelectorCancelled.Equal(err))
+		slMock.AssertExpectations(t)
+		stMock.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+
+	t.Run("does not allow editing active sessions from selector, and keeps it open", func(t *testing.T) {
+		// given
+		sessions := []project.Project{{Name: "barfoo", Type: project.TypeTmuxSession}}
+
+		slMock := new(test.MockProjectSelector)
+		slMock.On("SelectAction", sessions, mock.Anything).Return(selector.Selection{Project: &sessions[0], Action: selector.ActionEdit}, nil).Once()
+		slMock.On("SelectAction", sessions, mock.Anything).Return(selector.Selection{}, selector.ErrSelectorCancelled.WithMsg("operation cancelled")).Once()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project(nil), nil)
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil)
+
+		svc := &service.AppService{
+			Selector:    slMock,
+			Multiplexer: muMock,
+			Storage:     stMock,
+		}
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{})
+
+		// then
+		assert.True(t, selector.ErrSelectorCancelled.Equal(err))
+		slMock.AssertExpectations(t)
+		stMock.AssertNotCalled(t, "Find", mock.Anything)
+	})
+
+	t.Run("keeps selector open when creating project from empty query", func(t *testing.T) {
+		// given
+		slMock := new(test.MockProjectSelector)
+		slMock.On("SelectAction", mock.Anything, mock.Anything).Return(selector.Selection{Action: selector.ActionNew}, nil).Once()
+		slMock.On("SelectAction", mock.Anything, mock.Anything).Return(selector.Selection{}, selector.ErrSelectorCancelled.WithMsg("operation cancelled")).Once()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project(nil), nil)
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil)
+
+		svc := &service.AppService{
+			Multiplexer: muMock,
+			Selector:    slMock,
+			Storage:     stMock,
+		}
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{})
+
+		// then
+		assert.True(t, selector.ErrSelectorCancelled.Equal(err))
+		slMock.AssertExpectations(t)
+		stMock.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("keeps template when deleting it from selector is not confirmed", func(t *testing.T) {
+		// given
+		projects := []project.Project{{UUID: "1234", Name: "foobar"}}
+
+		slMock := new(test.MockProjectSelector)
+		slMock.On("SelectAction", mock.Anything, mock.Anything).Return(selector.Selection{Project: &projects[0], Action: selector.ActionDelete}, nil).Once()
+		slMock.On("SelectAction", mock.Anything, mock.Anything).Return(selector.Selection{}, selector.ErrSelectorCancelled.WithMsg("operation cancelled")).Once()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project(nil), nil)
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil)
+		prMock := new(test.MockPrompter)
+		prMock.On("Confirm", "Delete template foobar?").Return(false, nil).Once()
+
+		svc := &service.AppService{
+			Multiplexer: muMock,
+			Selector:    slMock,
+			Storage:     stMock,
+			Prompter:    prMock,
+		}
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{})
+
+		// then
+		assert.True(t, selector.ErrSelectorCancelled.Equal(err))
+		stMock.AssertNotCalled(t, "Delete", mock.Anything)
+		prMock.AssertExpectations(t)
+	})
+
+	t.Run("keeps selector open when killing template which is not running", func(t *testing.T) {
+		// given
+		projects := []project.Project{{UUID: "1234", Name: "foobar"}}
+
+		slMock := new(test.MockProjectSelector)
+		slMock.On("SelectAction", projects, mock.Anything).Return(selector.Selection{Project: &projects[0], Action: selector.ActionKill}, nil).Once()
+		slMock.On("SelectAction", projects, mock.Anything).Return(selector.Selection{}, selector.ErrSelectorCancelled.WithMsg("operation cancelled")).Once()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(projects, nil)
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil)
+		muMock.On("ResolveSessionName", mock.Anything).Return(multiplexer.SessionName("foobar"), nil)
+
+		svc := &service.AppService{
+			Selector:    slMock,
+			Multiplexer: muMock,
+			Storage:     stMock,
+		}
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{})
+
+		// then
+		assert.True(t, selector.ErrSelectorCancelled.Equal(err))
+		muMock.AssertNotCalled(t, "KillSession", mock.Anything)
+		slMock.AssertExpectations(t)
+	})
+
+	t.Run("does not allow killing discovered directories from selector, and keeps it open", func(t *testing.T) {
+		// given
+		dir := project.Project{Name: "api", Type: project.TypeDirectory, Template: template.Template{Root: "/code/api"}}
+
+		slMock := new(test.MockProjectSelector)
+		slMock.On("SelectAction", mock.Anything, mock.Anything).Return(selector.Selection{Project: &dir, Action: selector.ActionKill}, nil).Once()
+		slMock.On("SelectAction", mock.Anything, mock.Anything).Return(selector.Selection{}, selector.ErrSelectorCancelled.WithMsg("operation cancelled")).Once()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project(nil), nil)
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil)
+
+		svc := &service.AppService{
+			Selector:    slMock,
+			Multiplexer: muMock,
+			Storage:     stMock,
+		}
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{})
+
+		// then
+		assert.True(t, selector.ErrSelectorCancelled.Equal(err))
+		muMock.AssertNotCalled(t, "KillSession", mock.Anything)
+	})
 }
 
 func Test_DeleteProject(t *testing.T) {
@@ -575,7 +823,7 @@ func Test_KillSession(t *testing.T) {
 		}
 
 		// when
-		err := svc.KillSession("")
+		err := svc.KillSession("", service.KillOptions{})
 
 		// then
 		assert.Nil(t, err)
@@ -583,6 +831,26 @@ func Test_KillSession(t *testing.T) {
 		muMock.AssertExpectations(t)
 	})
 
+	t.Run("finds renamed session by its tmux name", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{Name: "foo", Type: project.TypeTmuxSession, Session: &project.Session{Name: "renamed", Managed: true}},
+		}
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(projects, nil).Once()
+		muMock.On("KillSession", projects[0]).Return(nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock}
+
+		// when
+		err := svc.KillSession("renamed", service.KillOptions{})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
 	t.Run("tries to find active session if name is provided", func(t *testing.T) {
 		// given
 		projects := []project.Project{
@@ -604,11 +872,1952 @@ func Test_KillSession(t *testing.T) {
 		}
 
 		// when
-		err := svc.KillSession("barfoo")
+		err := svc.KillSession("barfoo", service.KillOptions{})
+
+		// then
+		assert.Nil(t, err)
+		stMock.AssertExpectations(t)
+		muMock.AssertExpectations(t)
+	})
+}
+
+func Test_OpenProjects(t *testing.T) {
+	t.Run("runs multi-select, starts all and attaches to the first one", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{UUID: "1234", Name: "foo"},
+			{UUID: "5678", Name: "bar"},
+		}
+
+		slMock := new(test.MockProjectSelector)
+		slMock.On("SelectMany", projects, mock.Anything).Return(projects, nil).Once()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(projects, nil).Once()
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil).Once()
+		muMock.On("StartProject", projects[0]).Return(nil).Once()
+		muMock.On("StartProject", projects[1]).Return(nil).Once()
+		muMock.On("AttachProject", projects[0]).Return(nil).Once()
+
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", mock.Anything).Return(nil).Once()
+
+		svc := &service.AppService{
+			Selector:    slMock,
+			Multiplexer: muMock,
+			Storage:     stMock,
+			History:     hiMock,
+		}
+
+		// when
+		err := svc.OpenProjects(nil)
+
+		// then
+		assert.Nil(t, err)
+		slMock.AssertExpectations(t)
+		stMock.AssertExpectations(t)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("continues on failure and reports aggregated errors", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{UUID: "1234", Name: "foo"},
+			{UUID: "5678", Name: "bar"},
+		}
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(projects, nil).Once()
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil).Once()
+		muMock.On("StartProject", projects[0]).Return(errors.New("boom")).Once()
+		muMock.On("StartProject", projects[1]).Return(nil).Once()
+		muMock.On("AttachProject", projects[1]).Return(nil).Once()
+
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", mock.Anything).Return(nil).Once()
+
+		svc := &service.AppService{
+			Multiplexer: muMock,
+			Storage:     stMock,
+			History:     hiMock,
+		}
+
+		// when
+		err := svc.OpenProjects([]project.Name{"foo", "bar", "baz"})
+
+		// then
+		assert.True(t, service.ErrBatchFailed.Equal(err))
+		assert.Equal(t, "2 of 3 failed\n  baz: baz\n  foo: boom", err.Error())
+		stMock.AssertExpectations(t)
+		muMock.AssertExpectations(t)
+	})
+}
+
+func Test_DeleteProjects(t *testing.T) {
+	t.Run("runs multi-select and deletes all selected projects", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{UUID: "1234", Name: "foo"},
+			{UUID: "5678", Name: "bar"},
+		}
+
+		slMock := new(test.MockProjectSelector)
+		slMock.On("SelectMany", projects, mock.Anything).Return(projects, nil).Once()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(projects, nil).Once()
+		stMock.On("Delete", project.UUID("1234")).Return(nil).Once()
+		stMock.On("Delete", project.UUID("5678")).Return(nil).Once()
+
+		svc := &service.AppService{
+			Selector: slMock,
+			Storage:  stMock,
+		}
+
+		// when
+		err := svc.DeleteProjects(nil)
 
 		// then
 		assert.Nil(t, err)
+		slMock.AssertExpectations(t)
 		stMock.AssertExpectations(t)
+	})
+
+	t.Run("deletes remaining projects when one fails", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{UUID: "1234", Name: "foo"},
+			{UUID: "5678", Name: "bar"},
+		}
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(projects, nil).Once()
+		stMock.On("Delete", project.UUID("1234")).Return(errors.New("boom")).Once()
+		stMock.On("Delete", project.UUID("5678")).Return(nil).Once()
+
+		svc := &service.AppService{
+			Storage: stMock,
+		}
+
+		// when
+		err := svc.DeleteProjects([]project.Name{"foo", "bar"})
+
+		// then
+		assert.True(t, service.ErrBatchFailed.Equal(err))
+		assert.Equal(t, "1 of 2 failed\n  foo: boom", err.Error())
+		stMock.AssertExpectations(t)
+	})
+}
+
+func Test_KillSessions(t *testing.T) {
+	t.Run("runs multi-select and kills all selected sessions", func(t *testing.T) {
+		// given
+		sessions := []project.Project{
+			{Name: "foo", Type: project.TypeTmuxSession},
+			{Name: "bar", Type: project.TypeTmuxSession},
+		}
+
+		slMock := new(test.MockProjectSelector)
+		slMock.On("SelectMany", sessions, mock.Anything).Return(sessions, nil).Once()
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil).Once()
+		muMock.On("KillSession", sessions[0]).Return(nil).Once()
+		muMock.On("KillSession", sessions[1]).Return(nil).Once()
+
+		svc := &service.AppService{
+			Selector:    slMock,
+			Multiplexer: muMock,
+		}
+
+		// when
+		err := svc.KillSessions(nil, service.KillOptions{})
+
+		// then
+		assert.Nil(t, err)
+		slMock.AssertExpectations(t)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("propagates selector errors", func(t *testing.T) {
+		// given
+		expected := errors.New("expected error")
+
+		slMock := new(test.MockProjectSelector)
+		slMock.On("SelectMany", mock.Anything, mock.Anything).Return([]project.Project(nil), expected).Once()
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil).Once()
+
+		svc := &service.AppService{
+			Selector:    slMock,
+			Multiplexer: muMock,
+		}
+
+		// when
+		err := svc.KillSessions(nil, service.KillOptions{})
+
+		// then
+		assert.Equal(t, expected, err)
+		slMock.AssertExpectations(t)
+		muMock.AssertExpectations(t)
+	})
+}
+
+func Test_KillSessions_Bulk(t *testing.T) {
+	now := time.Unix(1792428202, 0)
+	sessions := []project.Project{
+		{Name: "api", Type: project.TypeTmuxSession, Session: &project.Session{Activity: now.Add(-3 * time.Hour)}},
+		{Name: "api/feature", Type: project.TypeTmuxSession, Session: &project.Session{Activity: now.Add(-time.Minute)}},
+		{Name: "notes", Type: project.TypeTmuxSession, Session: &project.Session{Activity: now.Add(-5 * time.Hour)}},
+	}
+
+	newMultiplexer := func(current multiplexer.SessionName) *test.MockMultiplexer {
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil).Once()
+		muMock.On("CurrentSession").Return(current, nil).Once()
+		return muMock
+	}
+
+	t.Run("kills all sessions once confirmed, the current one last", func(t *testing.T) {
+		// given
+		muMock := newMultiplexer("api")
+		killed := []project.Name{}
+		muMock.On("KillSession", mock.Anything).Run(func(args mock.Arguments) {
+			killed = append(killed, args.Get(0).(project.Project).Name)
+		}).Return(nil).Times(3)
+
+		prMock := new(test.MockPrompter)
+		prMock.On("Confirm", "Kill 3 sessions?").Return(true, nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock, Prompter: prMock}
+
+		// when
+		err := svc.KillSessions(nil, service.KillOptions{All: true})
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []project.Name{"api/feature", "notes", "api"}, killed)
+		muMock.AssertExpectations(t)
+		prMock.AssertExpectations(t)
+	})
+
+	t.Run("kills others without asking when confirmed up front", func(t *testing.T) {
+		// given
+		muMock := newMultiplexer("api")
+		muMock.On("KillSession", sessions[1]).Return(nil).Once()
+		muMock.On("KillSession", sessions[2]).Return(nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock}
+
+		// when
+		err := svc.KillSessions(nil, service.KillOptions{Others: true, Yes: true})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("refuses to kill others outside of tmux", func(t *testing.T) {
+		// given
+		muMock := newMultiplexer("")
+
+		svc := &service.AppService{Multiplexer: muMock}
+
+		// when
+		err := svc.KillSessions(nil, service.KillOptions{Others: true, Yes: true})
+
+		// then
+		assert.True(t, service.ErrNotInsideSession.Equal(err))
+		muMock.AssertNotCalled(t, "KillSession", mock.Anything)
+	})
+
+	t.Run("kills sessions idle for at least the duration", func(t *testing.T) {
+		// given
+		muMock := newMultiplexer("")
+		muMock.On("KillSession", sessions[0]).Return(nil).Once()
+		muMock.On("KillSession", sessions[2]).Return(nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock, Now: func() time.Time { return now }}
+
+		// when
+		err := svc.KillSessions(nil, service.KillOptions{Idle: 2 * time.Hour, Yes: true})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("kills sessions matching glob", func(t *testing.T) {
+		// given
+		muMock := newMultiplexer("")
+		muMock.On("KillSession", sessions[1]).Return(nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock}
+
+		// when
+		err := svc.KillSession("api/*", service.KillOptions{Yes: true})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("kills sessions matching regular expression", func(t *testing.T) {
+		// given
+		muMock := newMultiplexer("")
+		muMock.On("KillSession", sessions[0]).Return(nil).Once()
+		muMock.On("KillSession", sessions[1]).Return(nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock}
+
+		// when
+		err := svc.KillSessions([]project.Name{"^ap"}, service.KillOptions{Regex: true, Yes: true})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("leaves sessions running when declined", func(t *testing.T) {
+		// given
+		muMock := newMultiplexer("")
+
+		prMock := new(test.MockPrompter)
+		prMock.On("Confirm", "Kill 3 sessions?").Return(false, nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock, Prompter: prMock}
+
+		// when
+		err := svc.KillSessions(nil, service.KillOptions{All: true})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertNotCalled(t, "KillSession", mock.Anything)
+	})
+
+	t.Run("switches to chosen project before killing the current session", func(t *testing.T) {
+		// given
+		target := project.Project{UUID: "1234", Name: "web"}
+
+		muMock := new(test.MockMultiplexer)
+		// sessions are listed once more to match the template with its session
+		muMock.On("ListActiveSessions").Return(sessions, nil).Twice()
+		muMock.On("CurrentSession").Return(multiplexer.SessionName("notes"), nil).Once()
+		muMock.On("ResolveSessionName", sessions[2]).Return(multiplexer.SessionName("notes"), nil).Once()
+		muMock.On("ResolveSessionName", target).Return(multiplexer.SessionName("web"), nil).Once()
+		switched := muMock.On("AttachProject", target).Return(nil).Once()
+		muMock.On("KillSession", sessions[2]).Return(nil).Once().NotBefore(switched)
+
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("web")).Return(target, nil).Once()
+		stMock.On("List").Return([]project.Project{target}, nil).Once()
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", target).Return(nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock, Storage: stMock, History: hiMock}
+
+		// when
+		err := svc.KillSession("notes", service.KillOptions{SwitchTo: "web"})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("refuses to switch to the session being killed", func(t *testing.T) {
+		// given
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil).Once()
+		muMock.On("CurrentSession").Return(multiplexer.SessionName("notes"), nil).Once()
+		muMock.On("ResolveSessionName", sessions[2]).Return(multiplexer.SessionName("notes"), nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock}
+
+		// when
+		err := svc.KillSession("notes", service.KillOptions{SwitchTo: "notes"})
+
+		// then
+		assert.True(t, service.ErrInvalidSwitchTarget.Equal(err))
+		muMock.AssertNotCalled(t, "KillSession", mock.Anything)
+	})
+
+	t.Run("returns error for invalid pattern", func(t *testing.T) {
+		// given
+		muMock := new(test.MockMultiplexer)
+		svc := &service.AppService{Multiplexer: muMock}
+
+		// when
+		err := svc.KillSessions([]project.Name{"api("}, service.KillOptions{Regex: true})
+
+		// then
+		assert.True(t, service.ErrInvalidPattern.Equal(err))
+		muMock.AssertExpectations(t)
+	})
+}
+
+func Test_OpenLast(t *testing.T) {
+	t.Run("switches to last session known by tmux", func(t *testing.T) {
+		// given
+		projects := []project.Project{{UUID: "1234", Name: "foo"}}
+		sessions := []project.Project{{Name: "bar", Type: project.TypeTmuxSession}}
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(projects, nil).Once()
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil).Once()
+		muMock.On("PreviousSession").Return(multiplexer.SessionName("bar"), nil).Once()
+		// templates are matched with running sessions by name before looking for the previous one
+		muMock.On("ResolveSessionName", projects[0]).Return(multiplexer.SessionName("foo"), nil).Twice()
+		muMock.On("ResolveSessionName", sessions[0]).Return(multiplexer.SessionName("bar"), nil).Once()
+		muMock.On("AttachProject", sessions[0]).Return(nil).Once()
+
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", sessions[0]).Return(nil).Once()
+
+		svc := &service.AppService{
+			Multiplexer: muMock,
+			Storage:     stMock,
+			History:     hiMock,
+		}
+
+		// when
+		err := svc.OpenLast()
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+		hiMock.AssertExpectations(t)
+	})
+
+	t.Run("falls back to history and skips current session", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{UUID: "1234", Name: "foo"},
+			{UUID: "5678", Name: "bar"},
+		}
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(projects, nil).Once()
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil).Once()
+		muMock.On("PreviousSession").Return(multiplexer.SessionName(""), nil).Once()
+		muMock.On("CurrentSession").Return(multiplexer.SessionName("foo"), nil).Once()
+		muMock.On("ResolveSessionName", projects[0]).Return(multiplexer.SessionName("foo"), nil).Once()
+		muMock.On("ResolveSessionName", projects[1]).Return(multiplexer.SessionName("bar"), nil).Once()
+		// session of "bar" was killed, but it can be rebuilt from template
+		muMock.On("AttachProject", projects[1]).Return(nil).Once()
+
+		hiMock := new(test.MockHistory)
+		hiMock.On("Entries").Return([]history.Entry{
+			{UUID: "1234", Name: "foo", Count: 1},
+			{Name: "gone", Count: 3},
+			{UUID: "5678", Name: "bar", Count: 2},
+		}, nil).Once()
+		hiMock.On("Record", projects[1]).Return(nil).Once()
+
+		svc := &service.AppService{
+			Multiplexer: muMock,
+			Storage:     stMock,
+			History:     hiMock,
+		}
+
+		// when
+		err := svc.OpenLast()
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+		hiMock.AssertExpectations(t)
+	})
+
+	t.Run("returns error when there is nothing to go back to", func(t *testing.T) {
+		// given
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project(nil), nil).Once()
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil).Once()
+		muMock.On("PreviousSession").Return(multiplexer.SessionName(""), nil).Once()
+		muMock.On("CurrentSession").Return(multiplexer.SessionName(""), nil).Once()
+
+		hiMock := new(test.MockHistory)
+		hiMock.On("Entries").Return([]history.Entry(nil), nil).Once()
+
+		svc := &service.AppService{
+			Multiplexer: muMock,
+			Storage:     stMock,
+			History:     hiMock,
+		}
+
+		// when
+		err := svc.OpenLast()
+
+		// then
+		assert.True(t, service.ErrNoPreviousSession.Equal(err))
+	})
+}
+
+func Test_ListProjects(t *testing.T) {
+	projects := []project.Project{
+		{UUID: "1234", Name: "foo", Template: template.Template{Root: "/home/test", Windows: []window.Window{{Name: "main"}}}},
+		{UUID: "5678", Name: "Bar", Template: template.Template{Name: "bar-session", Root: "/home/bar"}},
+	}
+	sessions := []project.Project{
+		{Name: "foo", Type: project.TypeTmuxSession},
+		{Name: "baz", Type: project.TypeTmuxSession},
+	}
+
+	newService := func() *service.AppService {
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(slices.Clone(projects), nil).Once()
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil).Once()
+		for name, sessionName := range map[project.Name]multiplexer.SessionName{"foo": "foo", "Bar": "bar-session", "baz": "baz"} {
+			byName := mock.MatchedBy(func(p project.Project) bool { return p.Name == name })
+			muMock.On("ResolveSessionName", byName).Return(sessionName, nil)
+		}
+
+		return &service.AppService{Multiplexer: muMock, Storage: stMock}
+	}
+
+	t.Run("lists merged templates and sessions ordered by name", func(t *testing.T) {
+		// when
+		items, err := newService().ListProjects(service.ListFilter{})
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []service.ListItem{
+			{UUID: "5678", Name: "Bar", SessionName: "bar-session", Root: "/home/bar", Template: true},
+			{Name: "baz", SessionName: "baz", Running: true},
+			{UUID: "1234", Name: "foo", SessionName: "foo", Root: "/home/test", Windows: 1, Running: true, Template: true},
+		}, items)
+	})
+
+	t.Run("filters by kind and running state", func(t *testing.T) {
+		// when
+		templates, err := newService().ListProjects(service.ListFilter{Templates: true})
+		assert.Nil(t, err)
+
+		sessionsOnly, err := newService().ListProjects(service.ListFilter{Sessions: true})
+		assert.Nil(t, err)
+
+		runningTemplates, err := newService().ListProjects(service.ListFilter{Templates: true, Running: true})
+		assert.Nil(t, err)
+
+		// then
+		names := func(items []service.ListItem) []project.Name {
+			var names []project.Name
+			for _, item := range items {
+				names = append(names, item.Name)
+			}
+			return names
+		}
+		assert.Equal(t, []project.Name{"Bar", "foo"}, names(templates))
+		assert.Equal(t, []project.Name{"baz"}, names(sessionsOnly))
+		assert.Equal(t, []project.Name{"foo"}, names(runningTemplates))
+	})
+
+	t.Run("matches session tagged with template UUID and carries its metadata", func(t *testing.T) {
+		// given
+		activity := time.Unix(1792428202, 0).UTC()
+		templates := []project.Project{{UUID: "1234", Name: "foo", Template: template.Template{Root: "/home/test"}}}
+		sessions := []project.Project{{
+			Name: "renamed",
+			Type: project.TypeTmuxSession,
+			Session: &project.Session{
+				Attached: 1,
+				Windows:  3,
+				Activity: activity,
+				Path:     "/home/test",
+				Managed:  true,
+				UUID:     "1234",
+			},
+		}}
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(templates, nil).Once()
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil).Once()
+		muMock.On("ResolveSessionName", mock.Anything).Return(multiplexer.SessionName("foo"), nil)
+
+		svc := &service.AppService{Storage: stMock, Multiplexer: muMock}
+
+		// when
+		items, err := svc.ListProjects(service.ListFilter{})
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []service.ListItem{{
+			UUID:        "1234",
+			Name:        "foo",
+			SessionName: "foo",
+			Root:        "/home/test",
+			Windows:     3,
+			Running:     true,
+			Template:    true,
+			Attached:    true,
+			Activity:    &activity,
+			SessionPath: "/home/test",
+			Managed:     true,
+		}}, items)
+	})
+
+	t.Run("lists sessions of servers templates run on and matches them by socket", func(t *testing.T) {
+		// given
+		templates := []project.Project{{UUID: "1234", Name: "foo", Template: template.Template{Root: "/home/test", Socket: "work"}}}
+		sessions := []project.Project{{Name: "foo", Type: project.TypeTmuxSession, Session: &project.Session{Windows: 1}}}
+		workSessions := []project.Project{{Name: "foo", Type: project.TypeTmuxSession, Session: &project.Session{Windows: 2, Socket: "work"}}}
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(templates, nil).Once()
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil).Once()
+		muMock.On("ListSessionsOn", multiplexer.Socket("work")).Return(workSessions, nil).Once()
+		muMock.On("ResolveSessionName", mock.Anything).Return(multiplexer.SessionName("foo"), nil)
+
+		svc := &service.AppService{Storage: stMock, Multiplexer: muMock}
+
+		// when
+		items, err := svc.ListProjects(service.ListFilter{})
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []service.ListItem{
+			{UUID: "1234", Name: "foo", SessionName: "foo", Root: "/home/test", Windows: 2, Running: true, Template: true, Socket: "work", Activity: &time.Time{}},
+			{Name: "foo", SessionName: "foo", Windows: 1, Running: true, Activity: &time.Time{}},
+		}, items)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("lists storage once when templates inherit socket and are checked for staleness", func(t *testing.T) {
+		// given
+		templates := []project.Project{
+			{UUID: "1", Name: "base", Template: template.Template{Root: "/base", Socket: "work"}},
+			{UUID: "2", Name: "foo", Template: template.Template{Root: "/foo", Extends: "base"}},
+		}
+		workSessions := []project.Project{
+			{Name: "foo", Type: project.TypeTmuxSession, Session: &project.Session{Windows: 1, UUID: "2", Hash: "old", Socket: "work"}},
+		}
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(templates, nil).Once()
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil).Once()
+		muMock.On("ListSessionsOn", multiplexer.Socket("work")).Return(workSessions, nil).Once()
+		muMock.On("ResolveSessionName", mock.Anything).Return(multiplexer.SessionName("foo"), nil)
+
+		svc := &service.AppService{Storage: stMock, Multiplexer: muMock}
+
+		// when
+		items, err := svc.ListProjects(service.ListFilter{Running: true})
+
+		// then
+		assert.Nil(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, project.Name("foo"), items[0].Name)
+		assert.Equal(t, "work", items[0].Socket)
+		assert.True(t, items[0].Stale)
+		stMock.AssertExpectations(t)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("marks templates that can't be resolved", func(t *testing.T) {
+		// given
+		templates := []project.Project{
+			{UUID: "1", Name: "foo", Template: template.Template{Root: "/foo", Extends: "bar"}},
+			{UUID: "2", Name: "bar", Template: template.Template{Root: "/bar", Extends: "foo"}},
+			{UUID: "3", Name: "baz", Template: template.Template{Root: "/baz", Extends: "nope"}},
+		}
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(templates, nil).Once()
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil).Once()
+		muMock.On("ResolveSessionName", mock.Anything).Return(multiplexer.SessionName("x"), nil)
+
+		svc := &service.AppService{Storage: stMock, Multiplexer: muMock}
+
+		// when
+		items, err := svc.ListProjects(service.ListFilter{})
+
+		// then
+		assert.Nil(t, err)
+		errors := map[project.Name]string{}
+		for _, item := range items {
+			errors[item.Name] = item.Error
+		}
+		assert.Contains(t, errors["foo"], "foo -> bar -> foo")
+		assert.Contains(t, errors["bar"], "bar -> foo -> bar")
+		assert.Contains(t, errors["baz"], "extends nope, which doesn't exist")
+	})
+}
+
+func Test_StaleSessions(t *testing.T) {
+	t.Run("marks sessions built from an older version of the template", func(t *testing.T) {
+		// given
+		current := project.Project{UUID: "1234", Name: "foo", Template: template.Template{Root: "/home/foo"}}
+		changed := project.Project{UUID: "5678", Name: "bar", Template: template.Template{Root: "/home/bar"}}
+		templates := []project.Project{current, changed}
+		sessions := []project.Project{
+			{Name: "foo", Type: project.TypeTmuxSession, Session: &project.Session{UUID: "1234", Hash: current.Template.Hash()}},
+			{Name: "bar", Type: project.TypeTmuxSession, Session: &project.Session{UUID: "5678", Hash: "0123456789abcdef"}},
+		}
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(templates, nil).Once()
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil).Once()
+		muMock.On("ResolveSessionName", mock.Anything).Return(multiplexer.SessionName(""), nil)
+
+		svc := &service.AppService{Storage: stMock, Multiplexer: muMock}
+
+		// when
+		items, err := svc.ListProjects(service.ListFilter{})
+
+		// then
+		assert.Nil(t, err)
+		assert.Len(t, items, 2)
+		assert.Equal(t, project.Name("bar"), items[0].Name)
+		assert.True(t, items[0].Stale)
+		assert.Equal(t, project.Name("foo"), items[1].Name)
+		assert.False(t, items[1].Stale)
+	})
+
+	t.Run("rebuilds named project from the current template", func(t *testing.T) {
+		// given
+		p := project.Project{UUID: "1234", Name: "foo", Template: template.Template{Root: "/home/foo"}}
+
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("foo")).Return(p, nil).Once()
+		stMock.On("List").Return([]project.Project{p}, nil).Once()
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil).Once()
+		muMock.On("RebuildProject", p).Return(nil).Once()
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", p).Return(nil).Once()
+
+		svc := &service.AppService{Storage: stMock, Multiplexer: muMock, History: hiMock}
+
+		// when
+		err := svc.OpenProject("foo", service.OpenOptions{Rebuild: true})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+		muMock.AssertNotCalled(t, "AttachProject", mock.Anything)
+	})
+}
+
+func Test_ShowProject(t *testing.T) {
+	t.Run("resolves template and plans tmux commands", func(t *testing.T) {
+		// given
+		t.Setenv("HOME", "/home/test")
+		t.Setenv("PROJECTS", "/home/test/projects")
+
+		p := project.Project{
+			UUID: "1234",
+			Name: "foo",
+			Template: template.Template{
+				Root:    "~/foo",
+				Windows: []window.Window{{Name: "main", Root: "$PROJECTS/foo/src"}},
+			},
+		}
+		resolved := project.Project{
+			UUID: "1234",
+			Name: "foo",
+			Template: template.Template{
+				Root:    "/home/test/foo",
+				Windows: []window.Window{{Name: "main", Root: "/home/test/projects/foo/src"}},
+			},
+		}
+		commands := [][]string{{"tmux", "new-session"}}
+
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("foo")).Return(p, nil).Once()
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ResolveSessionName", resolved).Return(multiplexer.SessionName("foo"), nil).Once()
+		muMock.On("Plan", resolved).Return(commands, nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock, Storage: stMock}
+
+		// when
+		plan, err := svc.ShowProject("foo")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, service.ProjectPlan{Project: resolved, SessionName: "foo", Commands: commands}, plan)
+		assert.Equal(t, template.Root("~/foo"), p.Template.Root, "original project should not be modified")
+		assert.Equal(t, window.Root("$PROJECTS/foo/src"), p.Template.Windows[0].Root, "original project should not be modified")
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("propagates plan errors", func(t *testing.T) {
+		// given
+		expected := errors.New("expected error")
+		p := project.Project{UUID: "1234", Name: "foo"}
+
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("foo")).Return(p, nil).Once()
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ResolveSessionName", p).Return(multiplexer.SessionName("foo"), nil).Once()
+		muMock.On("Plan", p).Return([][]string(nil), expected).Once()
+
+		svc := &service.AppService{Multiplexer: muMock, Storage: stMock}
+
+		// when
+		_, err := svc.ShowProject("foo")
+
+		// then
+		assert.Equal(t, expected, err)
+	})
+}
+
+func Test_ExportScript(t *testing.T) {
+	t.Run("plans inherited template without expanding roots", func(t *testing.T) {
+		// given
+		t.Setenv("HOME", "/home/test")
+
+		base := project.Project{UUID: "1", Name: "base", Template: template.Template{Root: "~/x", Windows: []window.Window{{Name: "main"}}}}
+		p := project.Project{UUID: "2", Name: "foo", Template: template.Template{Extends: "base", Windows: []window.Window{{Name: "src", Root: "$PROJECTS/foo"}}}}
+		planned := project.Project{
+			UUID:     "2",
+			Name:     "foo",
+			Template: template.Template{Root: "~/x", Windows: []window.Window{{Name: "main"}, {Name: "src", Root: "$PROJECTS/foo"}}},
+		}
+		commands := [][]string{{"tmux", "new-session"}}
+
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("foo")).Return(p, nil).Once()
+		stMock.On("List").Return([]project.Project{base, p}, nil).Once()
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ResolveSessionName", planned).Return(multiplexer.SessionName("foo"), nil).Once()
+		muMock.On("Plan", planned).Return(commands, nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock, Storage: stMock}
+
+		// when
+		plan, err := svc.ExportScript("foo")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, service.ProjectPlan{Project: planned, SessionName: "foo", Commands: commands}, plan)
+		muMock.AssertExpectations(t)
+	})
+}
+
+func Test_ImportProjects(t *testing.T) {
+	t.Run("imports single config file", func(t *testing.T) {
+		// given
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadDir", "/tmp/blog.yml").Return([]os.DirEntry(nil), errors.New("not a directory")).Once()
+		fsMock.On("ReadFile", "/tmp/blog.yml").Return([]byte("name: blog\nroot: /code/blog\nwindows:\n  - editor: vim\n"), nil).Once()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project(nil), nil).Once()
+		stMock.On("Save", mock.MatchedBy(func(p *project.Project) bool {
+			return p.Name == "blog" &&
+				p.Template.Root == "/code/blog" &&
+				len(p.Template.Windows) == 1 &&
+				p.Template.Windows[0].Name == "editor"
+		})).Return(nil).Once()
+
+		svc := &service.AppService{
+			Storage:    stMock,
+			FileSystem: fsMock,
+		}
+
+		// when
+		err := svc.ImportProjects("/tmp/blog.yml", service.ImportOptions{From: importer.SourceTmuxinator})
+
+		// then
+		assert.Nil(t, err)
+		fsMock.AssertExpectations(t)
+		stMock.AssertExpectations(t)
+	})
+
+	t.Run("imports yaml files from directory and reports failures together", func(t *testing.T) {
+		// given
+		yml := new(test.MockDirEntry)
+		yml.On("Name").Return("blog.yml")
+		yml.On("IsDir").Return(false)
+		existing := new(test.MockDirEntry)
+		existing.On("Name").Return("api.yaml")
+		existing.On("IsDir").Return(false)
+		other := new(test.MockDirEntry)
+		other.On("Name").Return("notes.txt")
+		other.On("IsDir").Return(false)
+
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadDir", "/tmp/configs").Return([]os.DirEntry{yml, existing, other}, nil).Once()
+		fsMock.On("ReadFile", "/tmp/configs/blog.yml").Return([]byte("session_name: blog\nwindows: []\n"), nil).Once()
+		fsMock.On("ReadFile", "/tmp/configs/api.yaml").Return([]byte("session_name: api\n"), nil).Once()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project{{UUID: "1234", Name: "api"}}, nil).Once()
+		stMock.On("Save", mock.Anything).Return(nil).Once()
+
+		svc := &service.AppService{
+			Storage:    stMock,
+			FileSystem: fsMock,
+		}
+
+		// when
+		err := svc.ImportProjects("/tmp/configs", service.ImportOptions{From: importer.SourceTmuxp})
+
+		// then
+		assert.True(t, service.ErrBatchFailed.Equal(err))
+		assert.Contains(t, err.Error(), "1 of 2 failed")
+		assert.Contains(t, err.Error(), "api: project api already exists")
+		fsMock.AssertExpectations(t)
+		stMock.AssertExpectations(t)
+	})
+}
+
+func Test_ImportProjects_Bundle(t *testing.T) {
+	bundleFile := []byte(`version: 1
+projects:
+- uuid: aaaa
+  name: foo
+  version: 1
+  template:
+    root: /home/alice/foo
+    windows:
+    - name: main
+      root: /home/alice/foo/src
+- uuid: bbbb
+  name: bar
+  version: 1
+  template:
+    root: /opt/bar
+    windows:
+    - name: main
+`)
+
+	newBundleMocks := func() *test.MockFileSystem {
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadDir", "/tmp/bundle.yaml").Return([]os.DirEntry(nil), errors.New("not a directory")).Once()
+		fsMock.On("ReadFile", "/tmp/bundle.yaml").Return(bundleFile, nil).Once()
+		return fsMock
+	}
+
+	t.Run("rewrites roots and keeps uuids when requested", func(t *testing.T) {
+		// given
+		fsMock := newBundleMocks()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project(nil), nil).Once()
+		stMock.On("Save", mock.MatchedBy(func(p *project.Project) bool {
+			return p.UUID == "aaaa" &&
+				p.Template.Root == "/home/bob/foo" &&
+				p.Template.Windows[0].Root == "/home/bob/foo/src"
+		})).Return(nil).Once()
+		stMock.On("Save", mock.MatchedBy(func(p *project.Project) bool {
+			return p.UUID == "bbbb" && p.Template.Root == "/opt/bar"
+		})).Return(nil).Once()
+
+		svc := &service.AppService{
+			Storage:    stMock,
+			FileSystem: fsMock,
+		}
+
+		// when
+		err := svc.ImportProjects("/tmp/bundle.yaml", service.ImportOptions{
+			Roots:    []importer.RootMapping{{From: "/home/alice", To: "/home/bob"}},
+			KeepUUID: true,
+		})
+
+		// then
+		assert.Nil(t, err)
+		stMock.AssertExpectations(t)
+	})
+
+	t.Run("skips existing projects", func(t *testing.T) {
+		// given
+		fsMock := newBundleMocks()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project{{UUID: "1234", Name: "foo"}}, nil).Once()
+		stMock.On("Save", mock.MatchedBy(func(p *project.Project) bool {
+			return p.Name == "bar" && p.UUID == ""
+		})).Return(nil).Once()
+
+		svc := &service.AppService{
+			Storage:    stMock,
+			FileSystem: fsMock,
+		}
+
+		// when
+		err := svc.ImportProjects("/tmp/bundle.yaml", service.ImportOptions{Conflict: service.ConflictSkip})
+
+		// then
+		assert.Nil(t, err)
+		stMock.AssertExpectations(t)
+	})
+
+	t.Run("overwrites existing projects reusing their uuid", func(t *testing.T) {
+		// given
+		fsMock := newBundleMocks()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project{{UUID: "1234", Name: "foo"}}, nil).Once()
+		stMock.On("Save", mock.MatchedBy(func(p *project.Project) bool {
+			return p.Name == "foo" && p.UUID == "1234"
+		})).Return(nil).Once()
+		stMock.On("Save", mock.MatchedBy(func(p *project.Project) bool {
+			return p.Name == "bar"
+		})).Return(nil).Once()
+
+		svc := &service.AppService{
+			Storage:    stMock,
+			FileSystem: fsMock,
+		}
+
+		// when
+		err := svc.ImportProjects("/tmp/bundle.yaml", service.ImportOptions{Conflict: service.ConflictOverwrite})
+
+		// then
+		assert.Nil(t, err)
+		stMock.AssertExpectations(t)
+	})
+
+	t.Run("overwrites existing projects with kept uuid by deleting them first", func(t *testing.T) {
+		// given
+		fsMock := newBundleMocks()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project{{UUID: "1234", Name: "foo"}}, nil).Once()
+		stMock.On("Delete", project.UUID("1234")).Return(nil).Once()
+		stMock.On("Save", mock.Anything).Return(nil).Twice()
+
+		svc := &service.AppService{
+			Storage:    stMock,
+			FileSystem: fsMock,
+		}
+
+		// when
+		err := svc.ImportProjects("/tmp/bundle.yaml", service.ImportOptions{Conflict: service.ConflictOverwrite, KeepUUID: true})
+
+		// then
+		assert.Nil(t, err)
+		stMock.AssertExpectations(t)
+	})
+
+	t.Run("renames conflicting projects to first free name", func(t *testing.T) {
+		// given
+		fsMock := newBundleMocks()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project{{UUID: "1234", Name: "foo"}, {UUID: "5678", Name: "foo-2"}}, nil).Once()
+		stMock.On("Save", mock.MatchedBy(func(p *project.Project) bool {
+			return p.Name == "foo-3"
+		})).Return(nil).Once()
+		stMock.On("Save", mock.MatchedBy(func(p *project.Project) bool {
+			return p.Name == "bar"
+		})).Return(nil).Once()
+
+		svc := &service.AppService{
+			Storage:    stMock,
+			FileSystem: fsMock,
+		}
+
+		// when
+		err := svc.ImportProjects("/tmp/bundle.yaml", service.ImportOptions{Conflict: service.ConflictRename})
+
+		// then
+		assert.Nil(t, err)
+		stMock.AssertExpectations(t)
+	})
+
+	t.Run("fails when kept uuid belongs to another project", func(t *testing.T) {
+		// given
+		fsMock := newBundleMocks()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project{{UUID: "aaaa", Name: "other"}}, nil).Once()
+		stMock.On("Save", mock.Anything).Return(nil).Once()
+
+		svc := &service.AppService{
+			Storage:    stMock,
+			FileSystem: fsMock,
+		}
+
+		// when
+		err := svc.ImportProjects("/tmp/bundle.yaml", service.ImportOptions{KeepUUID: true})
+
+		// then
+		assert.True(t, service.ErrBatchFailed.Equal(err))
+		assert.Contains(t, err.Error(), "foo: uuid aaaa is already used by project other")
+		stMock.AssertExpectations(t)
+	})
+
+	t.Run("fails projects whose kept uuid would escape templates directory", func(t *testing.T) {
+		// given
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadDir", "/tmp/bundle.yaml").Return([]os.DirEntry(nil), errors.New("not a directory")).Once()
+		fsMock.On("ReadFile", "/tmp/bundle.yaml").Return([]byte(`version: 1
+projects:
+- uuid: ../../x
+  name: foo
+  version: 1
+  template:
+    root: /tmp
+    windows:
+    - name: main
+- uuid: ..
+  name: bar
+  version: 1
+  template:
+    root: /tmp
+    windows:
+    - name: main
+`), nil).Once()
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project(nil), nil).Once()
+
+		svc := &service.AppService{
+			Storage:    stMock,
+			FileSystem: fsMock,
+		}
+
+		// when
+		err := svc.ImportProjects("/tmp/bundle.yaml", service.ImportOptions{KeepUUID: true})
+
+		// then
+		assert.True(t, service.ErrBatchFailed.Equal(err))
+		assert.Contains(t, err.Error(), "foo: project foo has invalid uuid ../../x")
+		assert.Contains(t, err.Error(), "bar: project bar has invalid uuid ..")
+		stMock.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("returns error for unknown conflict strategy", func(t *testing.T) {
+		// given
+		svc := &service.AppService{}
+
+		// when
+		err := svc.ImportProjects("/tmp/bundle.yaml", service.ImportOptions{Conflict: "merge"})
+
+		// then
+		assert.True(t, service.ErrUnknownConflictStrategy.Equal(err))
+	})
+}
+
+func Test_ExportProjects(t *testing.T) {
+	t.Run("exports all templates with uuids", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{UUID: "1234", Name: "foo"},
+			{UUID: "5678", Name: "bar"},
+		}
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(projects, nil).Once()
+
+		svc := &service.AppService{
+			Storage: stMock,
+		}
+
+		// when
+		b, err := svc.ExportProjects(nil, true)
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, projects, b.List())
+		stMock.AssertExpectations(t)
+	})
+
+	t.Run("exports named templates and fails on unknown ones", func(t *testing.T) {
+		// given
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return([]project.Project{{UUID: "1234", Name: "foo"}}, nil).Once()
+
+		svc := &service.AppService{
+			Storage: stMock,
+		}
+
+		// when
+		_, err := svc.ExportProjects([]project.Name{"foo", "baz"}, false)
+
+		// then
+		assert.True(t, service.ErrBatchFailed.Equal(err))
+		assert.Contains(t, err.Error(), "baz")
+	})
+}
+
+func Test_SharedTemplates(t *testing.T) {
+	t.Run("forks shared template into personal storage before editing", func(t *testing.T) {
+		// given
+		shared := project.Project{UUID: "1234", Name: "foo", Source: "team"}
+
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("foo")).Return(shared, nil).Once()
+		stMock.On("Save", mock.MatchedBy(func(p *project.Project) bool {
+			return p.Name == "foo" && p.UUID == "" && p.Source == ""
+		})).Run(func(args mock.Arguments) {
+			args.Get(0).(*project.Project).UUID = "5678"
+		}).Return(nil).Once()
+		stMock.On("PrepareTemplateFile", project.Project{UUID: "5678", Name: "foo"}).Return("/foo/template.yaml", nil).Once()
+
+		prMock := new(test.MockPrompter)
+		prMock.On("Confirm", mock.Anything).Return(true, nil).Once()
+
+		executorMock := new(test.MockExecutor)
+		executorMock.On("ExecuteInteractive", mock.Anything).Return(0, nil).Once()
+
+		svc := &service.AppService{
+			Storage:  stMock,
+			Config:   &config.Config{Editor: "vim"},
+			Prompter: prMock,
+			E:        executorMock,
+		}
+
+		// when
+		err := svc.EditProject("foo")
+
+		// then
+		assert.Nil(t, err)
+		stMock.AssertExpectations(t)
+		prMock.AssertExpectations(t)
+		executorMock.AssertExpectations(t)
+	})
+
+	t.Run("leaves shared template untouched when fork is declined", func(t *testing.T) {
+		// given
+		shared := project.Project{UUID: "1234", Name: "foo", Source: "team"}
+
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("foo")).Return(shared, nil).Once()
+
+		prMock := new(test.MockPrompter)
+		prMock.On("Confirm", mock.Anything).Return(false, nil).Once()
+
+		executorMock := new(test.MockExecutor)
+
+		svc := &service.AppService{
+			Storage:  stMock,
+			Config:   &config.Config{Editor: "vim"},
+			Prompter: prMock,
+			E:        executorMock,
+		}
+
+		// when
+		err := svc.EditProject("foo")
+
+		// then
+		assert.Nil(t, err)
+		stMock.AssertNotCalled(t, "Save", mock.Anything)
+		executorMock.AssertNotCalled(t, "ExecuteInteractive", mock.Anything)
+	})
+
+	t.Run("refuses to delete shared template", func(t *testing.T) {
+		// given
+		shared := project.Project{UUID: "1234", Name: "foo", Source: "team"}
+
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("foo")).Return(shared, nil).Once()
+
+		svc := &service.AppService{
+			Storage: stMock,
+		}
+
+		// when
+		err := svc.DeleteProject("foo")
+
+		// then
+		assert.True(t, service.ErrReadOnlyTemplate.Equal(err))
+		stMock.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+}
+
+func Test_Inheritance(t *testing.T) {
+	showResolved := func(t *testing.T, projects []project.Project, name project.Name) (project.Project, error) {
+		t.Helper()
+
+		stMock := new(test.MockStorage)
+		i := slices.IndexFunc(projects, func(p project.Project) bool { return p.Name == name })
+		stMock.On("Find", name).Return(projects[i], nil).Once()
+		stMock.On("List").Return(projects, nil)
+
+		var resolved project.Project
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ResolveSessionName", mock.Anything).Return(multiplexer.SessionName(name), nil).Maybe()
+		muMock.On("Plan", mock.Anything).Run(func(args mock.Arguments) {
+			resolved = args.Get(0).(project.Project)
+		}).Return([][]string(nil), nil).Maybe()
+
+		svc := &service.AppService{Multiplexer: muMock, Storage: stMock}
+		_, err := svc.ShowProject(name)
+		return resolved, err
+	}
+
+	t.Run("merges windows by name, appends new ones and removes marked ones", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{
+				UUID: "1",
+				Name: "base",
+				Template: template.Template{
+					Name:     "base-session",
+					Root:     "/base",
+					Commands: []command.Command{"source .env"},
+					Env:      template.Env{"A": "1", "B": "1"},
+					Windows: []window.Window{
+						{Name: "editor", Root: "/base/src", Commands: []command.Command{"nvim"}},
+						{Name: "server", Commands: []command.Command{"make run"}},
+						{Name: "git", Commands: []command.Command{"lazygit"}},
+					},
+				},
+			},
+			{
+				UUID: "2",
+				Name: "foo",
+				Template: template.Template{
+					Extends:  "base",
+					Root:     "/foo",
+					Commands: []command.Command{"nvm use"},
+					Env:      template.Env{"B": "2"},
+					Windows: []window.Window{
+						{Name: "editor", Root: "/foo/src", Commands: []command.Command{"git pull"}},
+						{Name: "server", Remove: true},
+						{Name: "logs", Commands: []command.Command{"tail -f log"}},
+						{Name: "missing", Remove: true},
+					},
+				},
+			},
+		}
+
+		// when
+		resolved, err := showResolved(t, projects, "foo")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, template.Template{
+			Root:     "/foo",
+			Commands: []command.Command{"source .env", "nvm use"},
+			Env:      template.Env{"A": "1", "B": "2"},
+			Windows: []window.Window{
+				{Name: "editor", Root: "/foo/src", Commands: []command.Command{"nvim", "git pull"}},
+				{Name: "git", Commands: []command.Command{"lazygit"}},
+				{Name: "logs", Commands: []command.Command{"tail -f log"}},
+			},
+		}, resolved.Template)
+	})
+
+	t.Run("merges whole chain and removes windows of distant bases", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{Name: "root", Template: template.Template{Root: "/root", Windows: []window.Window{{Name: "a"}, {Name: "b"}}}},
+			{Name: "middle", Template: template.Template{Extends: "root", Windows: []window.Window{{Name: "c"}}}},
+			{Name: "leaf", Template: template.Template{Extends: "middle", Windows: []window.Window{{Name: "a", Remove: true}}}},
+		}
+
+		// when
+		resolved, err := showResolved(t, projects, "leaf")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, template.Root("/root"), resolved.Template.Root)
+		assert.Equal(t, []window.Window{{Name: "b"}, {Name: "c"}}, resolved.Template.Windows)
+	})
+
+	t.Run("reports cycles", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{Name: "foo", Template: template.Template{Extends: "bar"}},
+			{Name: "bar", Template: template.Template{Extends: "foo"}},
+		}
+
+		// when
+		_, err := showResolved(t, projects, "foo")
+
+		// then
+		assert.True(t, service.ErrTemplateCycle.Equal(err))
+		assert.Contains(t, err.Error(), "foo -> bar -> foo")
+	})
+
+	t.Run("reports missing base template", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{Name: "foo", Template: template.Template{Extends: "nope"}},
+		}
+
+		// when
+		_, err := showResolved(t, projects, "foo")
+
+		// then
+		assert.True(t, service.ErrBaseTemplateNotFound.Equal(err))
+	})
+
+	t.Run("drops removed windows of templates extending nothing", func(t *testing.T) {
+		// given
+		projects := []project.Project{
+			{Name: "foo", Template: template.Template{Root: "/foo", Windows: []window.Window{{Name: "a"}, {Name: "b", Remove: true}}}},
+		}
+
+		// when
+		resolved, err := showResolved(t, projects, "foo")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []window.Window{{Name: "a"}}, resolved.Template.Windows)
+		assert.Len(t, projects[0].Template.Windows, 2, "stored template must be left intact")
+	})
+}
+
+func Test_Snippets(t *testing.T) {
+	t.Run("builds windows from snippets with overrides, before merging extended templates", func(t *testing.T) {
+		// given
+		base := project.Project{
+			Name: "base",
+			Template: template.Template{
+				Root:    "/base",
+				Windows: []window.Window{{Use: "git"}, {Use: "logs", Name: "server-logs"}},
+			},
+		}
+		p := project.Project{
+			Name: "foo",
+			Template: template.Template{
+				Extends: "base",
+				Root:    "/foo",
+				Windows: []window.Window{
+					{Name: "git", Root: "/foo/repo"},
+					{Use: "logs", Name: "app-logs", Commands: []command.Command{"tail -f app.log"}},
+				},
+			},
+		}
+
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("foo")).Return(p, nil).Once()
+		stMock.On("List").Return([]project.Project{base, p}, nil).Once()
+		stMock.On("FindSnippet", window.Snippet("git")).Return(window.Window{Commands: []command.Command{"lazygit"}}, nil)
+		stMock.On("FindSnippet", window.Snippet("logs")).Return(window.Window{Name: "logs", Root: "/var/log", Commands: []command.Command{"tail -f syslog"}}, nil)
+
+		var resolved project.Project
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ResolveSessionName", mock.Anything).Return(multiplexer.SessionName("foo"), nil).Once()
+		muMock.On("Plan", mock.Anything).Run(func(args mock.Arguments) {
+			resolved = args.Get(0).(project.Project)
+		}).Return([][]string(nil), nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock, Storage: stMock}
+
+		// when
+		_, err := svc.ShowProject("foo")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []window.Window{
+			{Name: "git", Root: "/foo/repo", Commands: []command.Command{"lazygit"}},
+			{Name: "server-logs", Root: "/var/log", Commands: []command.Command{"tail -f syslog"}},
+			{Name: "app-logs", Root: "/var/log", Commands: []command.Command{"tail -f app.log"}},
+		}, resolved.Template.Windows)
+		stMock.AssertExpectations(t)
+	})
+
+	t.Run("returns error for missing snippet", func(t *testing.T) {
+		// given
+		p := project.Project{
+			Name:     "foo",
+			Template: template.Template{Root: "/foo", Windows: []window.Window{{Use: "nope"}}},
+		}
+
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("foo")).Return(p, nil).Once()
+		stMock.On("FindSnippet", window.Snippet("nope")).Return(window.Window{}, storage.ErrSnippetNotFound.WithMsg("snippet nope not found")).Once()
+
+		svc := &service.AppService{Storage: stMock}
+
+		// when
+		_, err := svc.ShowProject("foo")
+
+		// then
+		assert.True(t, storage.ErrSnippetNotFound.Equal(err))
+	})
+}
+
+func Test_DiscoveredDirectories(t *testing.T) {
+	newService := func(templates []project.Project, dirs []string) (*service.AppService, *test.MockProjectSelector, *test.MockStorage, *test.MockMultiplexer) {
+		slMock := new(test.MockProjectSelector)
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(templates, nil)
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project{}, nil)
+		diMock := new(test.MockDiscoverer)
+		diMock.On("Discover").Return(dirs, nil)
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", mock.Anything).Return(nil)
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadDir", mock.Anything).Return([]os.DirEntry{}, nil)
+
+		svc := &service.AppService{
+			Selector:    slMock,
+			Multiplexer: muMock,
+			Storage:     stMock,
+			History:     hiMock,
+			Discoverer:  diMock,
+			FileSystem:  fsMock,
+		}
+		return svc, slMock, stMock, muMock
+	}
+
+	dirProject := func(dir string, name project.Name) project.Project {
+		return project.Project{Name: name, Type: project.TypeDirectory, Template: template.Template{Root: template.Root(dir)}}
+	}
+
+	t.Run("lists directories after templates, skipping template roots", func(t *testing.T) {
+		// given
+		home, _ := os.UserHomeDir()
+		templates := []project.Project{{UUID: "1234", Name: "api", Template: template.Template{Root: "~/code/api"}}}
+		svc, slMock, _, _ := newService(templates, []string{home + "/code/api", "/code/web"})
+
+		expected := append(slices.Clone(templates), dirProject("/code/web", "web"))
+		slMock.On("SelectAction", expected, mock.Anything).Return(selector.Selection{}, selector.ErrSelectorCancelled.WithMsg("cancelled")).Once()
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{})
+
+		// then
+		assert.True(t, selector.ErrSelectorCancelled.Equal(err))
+		slMock.AssertExpectations(t)
+	})
+
+	t.Run("opens directory with the default template", func(t *testing.T) {
+		// given
+		svc, slMock, stMock, muMock := newService([]project.Project{}, []string{"/code/web"})
+
+		dir := dirProject("/code/web", "web")
+		slMock.On("SelectAction", []project.Project{dir}, mock.Anything).Return(selector.Selection{Project: &dir, Action: selector.ActionOpen}, nil).Once()
+		muMock.On("AttachProject", mock.MatchedBy(func(p project.Project) bool {
+			return p.Name == "web" && p.Type == project.TypeTemplate && p.Template.Root == "/code/web" &&
+				len(p.Template.Windows) == 1 && p.Template.Windows[0].Name == "shell"
+		})).Return(nil).Once()
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{})
+
+		// then
+		assert.Nil(t, err)
+		stMock.AssertNotCalled(t, "Save", mock.Anything)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("saves directory as a project when asked to", func(t *testing.T) {
+		// given
+		svc, slMock, stMock, muMock := newService([]project.Project{}, []string{"/code/web"})
+
+		dir := dirProject("/code/web", "web")
+		slMock.On("SelectAction", []project.Project{dir}, mock.Anything).Return(selector.Selection{Project: &dir, Action: selector.ActionOpen}, nil).Once()
+		stMock.On("Save", mock.MatchedBy(func(p *project.Project) bool {
+			return p.Name == "web" && p.Template.Root == "/code/web"
+		})).Return(nil).Once()
+		muMock.On("AttachProject", mock.Anything).Return(nil).Once()
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{Save: true})
+
+		// then
+		assert.Nil(t, err)
+		stMock.AssertExpectations(t)
+		muMock.AssertExpectations(t)
+	})
+}
+
+func Test_Worktrees(t *testing.T) {
+	api := project.Project{
+		UUID:    "1234",
+		Name:    "api",
+		Version: 1,
+		Template: template.Template{
+			Root: "/code/api",
+			Windows: []window.Window{
+				{Name: "docs", Root: "/code/api/docs"},
+				{Name: "notes", Root: "/notes"},
+			},
+		},
+	}
+
+	mainWorktree := git.Worktree{Path: "/code/api", Branch: "main"}
+
+	newService := func(worktrees []git.Worktree) (*service.AppService, *test.MockGitClient, *test.MockMultiplexer) {
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("api")).Return(api, nil)
+		stMock.On("List").Return([]project.Project{api}, nil)
+		stMock.On("FindSnippet", mock.Anything).Return(window.Window{}, nil)
+		gitMock := new(test.MockGitClient)
+		gitMock.On("ListWorktrees", "/code/api").Return(worktrees, nil)
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ResolveSessionName", mock.Anything).Return(multiplexer.SessionName("api"), nil).Maybe()
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", mock.Anything).Return(nil)
+
+		svc := &service.AppService{
+			Selector:    new(test.MockProjectSelector),
+			Multiplexer: muMock,
+			Storage:     stMock,
+			History:     hiMock,
+			Git:         gitMock,
+		}
+		return svc, gitMock, muMock
+	}
+
+	rebased := func(p project.Project) bool {
+		return p.Name == "api/feature/login" && p.UUID == "" &&
+			p.Template.Name == "api/feature/login" &&
+			p.Template.Root == "/code/api.worktrees/feature-login" &&
+			p.Template.Windows[0].Root == "/code/api.worktrees/feature-login/docs" &&
+			p.Template.Windows[1].Root == "/notes"
+	}
+
+	t.Run("creates worktree for new branch and opens it rebased", func(t *testing.T) {
+		// given
+		svc, gitMock, muMock := newService([]git.Worktree{mainWorktree})
+		gitMock.On("BranchExists", "/code/api", "feature/login").Return(false, nil).Once()
+		gitMock.On("AddWorktree", "/code/api", "/code/api.worktrees/feature-login", "feature/login", true).Return(nil).Once()
+		muMock.On("AttachProject", mock.MatchedBy(rebased)).Return(nil).Once()
+
+		// when
+		err := svc.OpenProject("api", service.OpenOptions{Worktree: "feature/login"})
+
+		// then
+		assert.Nil(t, err)
+		gitMock.AssertExpectations(t)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("reuses existing worktree of the branch", func(t *testing.T) {
+		// given
+		svc, gitMock, muMock := newService([]git.Worktree{
+			mainWorktree,
+			{Path: "/code/api.worktrees/feature-login", Branch: "feature/login"},
+		})
+		muMock.On("AttachProject", mock.MatchedBy(rebased)).Return(nil).Once()
+
+		// when
+		err := svc.OpenProject("api", service.OpenOptions{Worktree: "feature/login"})
+
+		// then
+		assert.Nil(t, err)
+		gitMock.AssertNotCalled(t, "AddWorktree", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("opens the project itself for branch of the main worktree", func(t *testing.T) {
+		// given
+		svc, _, muMock := newService([]git.Worktree{mainWorktree})
+		muMock.On("AttachProject", mock.MatchedBy(func(p project.Project) bool { return p.UUID == "1234" })).Return(nil).Once()
+
+		// when
+		err := svc.OpenProject("api", service.OpenOptions{Worktree: "main"})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("returns error when there are no worktrees to select from", func(t *testing.T) {
+		// given
+		svc, _, _ := newService([]git.Worktree{mainWorktree})
+
+		// when
+		err := svc.OpenProject("api", service.OpenOptions{PickWorktree: true})
+
+		// then
+		assert.True(t, service.ErrNoWorktrees.Equal(err))
+	})
+
+	t.Run("removes clean worktree of killed session", func(t *testing.T) {
+		// given
+		session := project.Project{Name: "api/feature/login", Type: project.TypeTmuxSession}
+		svc, gitMock, muMock := newService([]git.Worktree{
+			mainWorktree,
+			{Path: "/code/api.worktrees/feature-login", Branch: "feature/login"},
+		})
+		muMock.On("ListActiveSessions").Return([]project.Project{session}, nil).Once()
+		muMock.On("KillSession", session).Return(nil).Once()
+		gitMock.On("IsClean", "/code/api.worktrees/feature-login").Return(true, nil).Once()
+		gitMock.On("RemoveWorktree", "/code/api", "/code/api.worktrees/feature-login").Return(nil).Once()
+
+		// when
+		err := svc.KillSession("api/feature/login", service.KillOptions{RemoveWorktree: true})
+
+		// then
+		assert.Nil(t, err)
+		gitMock.AssertExpectations(t)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("keeps worktree with changes", func(t *testing.T) {
+		// given
+		session := project.Project{Name: "api/feature/login", Type: project.TypeTmuxSession}
+		svc, gitMock, muMock := newService([]git.Worktree{
+			mainWorktree,
+			{Path: "/code/api.worktrees/feature-login", Branch: "feature/login"},
+		})
+		muMock.On("ListActiveSessions").Return([]project.Project{session}, nil).Once()
+		muMock.On("KillSession", session).Return(nil).Once()
+		gitMock.On("IsClean", "/code/api.worktrees/feature-login").Return(false, nil).Once()
+
+		// when
+		err := svc.KillSession("api/feature/login", service.KillOptions{RemoveWorktree: true})
+
+		// then
+		assert.Nil(t, err)
+		gitMock.AssertNotCalled(t, "RemoveWorktree", mock.Anything, mock.Anything)
+	})
+}
+
+func Test_OpenDirectory(t *testing.T) {
+	newService := func(templates []project.Project, sessions []project.Project) (*service.AppService, *test.MockStorage, *test.MockMultiplexer) {
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(templates, nil)
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil).Maybe()
+		for _, p := range templates {
+			muMock.On("ResolveSessionName", p).Return(multiplexer.SessionName(p.Name), nil).Maybe()
+		}
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", mock.Anything).Return(nil)
+		fsMock := new(test.MockFileSystem)
+		fsMock.On("ReadDir", "/code/web").Return([]os.DirEntry{}, nil)
+		fsMock.On("ReadDir", mock.Anything).Return([]os.DirEntry(nil), os.ErrNotExist)
+
+		svc := &service.AppService{
+			Multiplexer: muMock,
+			Storage:     stMock,
+			History:     hiMock,
+			FileSystem:  fsMock,
+		}
+		return svc, stMock, muMock
+	}
+
+	opened := func(name template.Name, root template.Root) any {
+		return mock.MatchedBy(func(p project.Project) bool {
+			return p.Type == project.TypeTemplate && p.UUID == "" && p.Name == project.Name(name) && p.Template.Root == root
+		})
+	}
+
+	t.Run("opens directory named after its basename without saving it", func(t *testing.T) {
+		// given
+		svc, stMock, muMock := newService([]project.Project{}, []project.Project{})
+		muMock.On("AttachProject", opened("web", "/code/web")).Return(nil).Once()
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{Path: "/code/web/"})
+
+		// then
+		assert.Nil(t, err)
+		stMock.AssertNotCalled(t, "Save", mock.Anything)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("suffixes name taken by a template or session of another directory", func(t *testing.T) {
+		// given
+		templates := []project.Project{{UUID: "1234", Name: "web", Template: template.Template{Root: "/other/web"}}}
+		sessions := []project.Project{{Name: "web-2", Type: project.TypeTmuxSession}}
+		svc, _, muMock := newService(templates, sessions)
+		muMock.On("SessionRoot", multiplexer.SessionName("web-2")).Return("/srv/web", nil).Once()
+		muMock.On("AttachProject", opened("web-3", "/code/web")).Return(nil).Once()
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{Path: "/code/web"})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("reuses session of the same directory", func(t *testing.T) {
+		// given
+		sessions := []project.Project{{Name: "web", Type: project.TypeTmuxSession}}
+		svc, _, muMock := newService([]project.Project{}, sessions)
+		muMock.On("SessionRoot", multiplexer.SessionName("web")).Return("/code/web", nil).Once()
+		muMock.On("AttachProject", opened("web", "/code/web")).Return(nil).Once()
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{Path: "/code/web"})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("opens template rooted in the directory", func(t *testing.T) {
+		// given
+		templates := []project.Project{{UUID: "1234", Name: "frontend", Template: template.Template{Root: "/code/web"}}}
+		svc, _, muMock := newService(templates, []project.Project{})
+		muMock.On("AttachProject", mock.MatchedBy(func(p project.Project) bool { return p.UUID == "1234" })).Return(nil).Once()
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{Path: "/code/web"})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("saves directory as a project when asked to", func(t *testing.T) {
+		// given
+		svc, stMock, muMock := newService([]project.Project{}, []project.Project{})
+		stMock.On("Save", mock.MatchedBy(func(p *project.Project) bool { return p.Name == "web" })).Return(nil).Once()
+		muMock.On("AttachProject", opened("web", "/code/web")).Return(nil).Once()
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{Path: "/code/web", Save: true})
+
+		// then
+		assert.Nil(t, err)
+		stMock.AssertExpectations(t)
+	})
+
+	t.Run("returns error for missing directory", func(t *testing.T) {
+		// given
+		svc, _, _ := newService([]project.Project{}, []project.Project{})
+
+		// when
+		err := svc.OpenProject("", service.OpenOptions{Path: "/code/missing"})
+
+		// then
+		assert.True(t, service.ErrDirectoryNotFound.Equal(err))
+	})
+}
+
+func Test_SanitizedSessionNames(t *testing.T) {
+	t.Run("kills session looked up by its original name", func(t *testing.T) {
+		// given
+		session := project.Project{Name: "my_app", Type: project.TypeTmuxSession}
+
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project{session}, nil).Once()
+		muMock.On("KillSession", session).Return(nil).Once()
+
+		svc := &service.AppService{Multiplexer: muMock}
+
+		// when
+		err := svc.KillSession("my.app", service.KillOptions{})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("merges session listed under original name into dotted template", func(t *testing.T) {
+		// given
+		templates := []project.Project{{UUID: "1234", Name: "my.app"}}
+		sessions := []project.Project{{Name: "my.app", Type: project.TypeTmuxSession}}
+
+		stMock := new(test.MockStorage)
+		stMock.On("List").Return(templates, nil).Once()
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return(sessions, nil).Once()
+		muMock.On("ResolveSessionName", mock.Anything).Return(multiplexer.SessionName("my_app"), nil)
+
+		svc := &service.AppService{Storage: stMock, Multiplexer: muMock}
+
+		// when
+		items, err := svc.ListProjects(service.ListFilter{})
+
+		// then
+		assert.Nil(t, err)
+		assert.Len(t, items, 1)
+		assert.True(t, items[0].Running)
+	})
+}
+
+func Test_ReloadProject(t *testing.T) {
+	p := project.Project{UUID: "1234", Name: "foo", Template: template.Template{Root: "/home/foo"}}
+
+	newService := func(muMock *test.MockMultiplexer) *service.AppService {
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("foo")).Return(p, nil).Once()
+		stMock.On("List").Return([]project.Project{p}, nil).Once()
+		muMock.On("ListActiveSessions").Return([]project.Project(nil), nil).Once()
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", mock.Anything).Return(nil).Once()
+
+		return &service.AppService{Storage: stMock, Multiplexer: muMock, History: hiMock}
+	}
+
+	t.Run("recreates the session by default", func(t *testing.T) {
+		// given
+		muMock := new(test.MockMultiplexer)
+		muMock.On("RebuildProject", p).Return(nil).Once()
+
+		// when
+		err := newService(muMock).ReloadProject("foo", service.ReloadOptions{})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("only adds what's missing when incremental", func(t *testing.T) {
+		// given
+		muMock := new(test.MockMultiplexer)
+		muMock.On("UpdateProject", p).Return(nil).Once()
+
+		// when
+		err := newService(muMock).ReloadProject("foo", service.ReloadOptions{Incremental: true})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("rebuilds session matched by template UUID under a different name", func(t *testing.T) {
+		// given
+		session := project.Project{
+			Name:    "old-name",
+			Type:    project.TypeTmuxSession,
+			Session: &project.Session{Name: "old-name", Managed: true, UUID: "1234"},
+		}
+		matched := p
+		matched.Running = true
+		matched.Session = session.Session
+
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("foo")).Return(p, nil).Once()
+		stMock.On("List").Return([]project.Project{p}, nil).Once()
+		muMock := new(test.MockMultiplexer)
+		muMock.On("ListActiveSessions").Return([]project.Project{session}, nil).Once()
+		muMock.On("ResolveSessionName", p).Return(multiplexer.SessionName("foo"), nil).Once()
+		muMock.On("RebuildProject", matched).Return(nil).Once()
+		hiMock := new(test.MockHistory)
+		hiMock.On("Record", mock.Anything).Return(nil).Once()
+
+		svc := &service.AppService{Storage: stMock, Multiplexer: muMock, History: hiMock}
+
+		// when
+		err := svc.ReloadProject("foo", service.ReloadOptions{})
+
+		// then
+		assert.Nil(t, err)
+		muMock.AssertExpectations(t)
+	})
+
+	t.Run("returns error when project doesn't exist", func(t *testing.T) {
+		// given
+		stMock := new(test.MockStorage)
+		stMock.On("Find", project.Name("bar")).Return(project.Project{}, storage.ErrProjectNotFound.WithMsg("bar")).Once()
+		muMock := new(test.MockMultiplexer)
+
+		svc := &service.AppService{Storage: stMock, Multiplexer: muMock}
+
+		// when
+		err := svc.ReloadProject("bar", service.ReloadOptions{})
+
+		// then
+		assert.True(t, storage.ErrProjectNotFound.Equal(err))
 		muMock.AssertExpectations(t)
 	})
 }
@@ -0,0 +1,139 @@
+package storage_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"thop/internal/storage"
+	"thop/internal/types/project"
+	"thop/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSharedStorage(name project.Source, dir string, templates map[string]string) *storage.SharedStorage {
+	fs := new(test.MockFileSystem)
+
+	var entries []os.DirEntry
+	for uuid, content := range templates {
+		entry := new(test.MockDirEntry)
+		entry.On("IsDir").Return(true)
+		entry.On("Name").Return(uuid)
+		entries = append(entries, entry)
+		fs.On("ReadFile", dir+"/"+uuid+"/template.yaml").Return([]byte(content), nil)
+	}
+	fs.On("ReadDir", dir).Return(entries, nil)
+
+	return &storage.SharedStorage{Name: name, Dir: dir, FileSystem: fs}
+}
+
+func Test_LayeredStorage_List(t *testing.T) {
+	t.Run("merges shared templates with personal ones taking precedence", func(t *testing.T) {
+		// given
+		personal := new(test.MockStorage)
+		personal.On("List").Return([]project.Project{{UUID: "1", Name: "foo"}}, nil).Once()
+
+		team := newSharedStorage("team", "/mnt/team", map[string]string{
+			"2": "name: foo\n",
+			"3": "name: bar\n",
+		})
+		infra := newSharedStorage("infra", "/mnt/infra", map[string]string{
+			"4": "name: bar\n",
+		})
+
+		st := &storage.LayeredStorage{Personal: personal, Shared: []*storage.SharedStorage{team, infra}}
+
+		// when
+		projects, err := st.List()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []project.Project{
+			{UUID: "1", Name: "foo"},
+			{UUID: "3", Name: "bar", Source: "team"},
+		}, projects)
+	})
+
+	t.Run("skips unavailable sources", func(t *testing.T) {
+		// given
+		personal := new(test.MockStorage)
+		personal.On("List").Return([]project.Project{{UUID: "1", Name: "foo"}}, nil).Once()
+
+		fs := new(test.MockFileSystem)
+		fs.On("ReadDir", "/mnt/team").Return([]os.DirEntry(nil), errors.New("no such file or directory")).Once()
+		team := &storage.SharedStorage{Name: "team", Dir: "/mnt/team", FileSystem: fs}
+
+		st := &storage.LayeredStorage{Personal: personal, Shared: []*storage.SharedStorage{team}}
+
+		// when
+		projects, err := st.List()
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, []project.Project{{UUID: "1", Name: "foo"}}, projects)
+	})
+}
+
+func Test_LayeredStorage_Find(t *testing.T) {
+	t.Run("finds shared template", func(t *testing.T) {
+		// given
+		personal := new(test.MockStorage)
+		personal.On("List").Return([]project.Project(nil), nil).Once()
+
+		team := newSharedStorage("team", "/mnt/team", map[string]string{"2": "name: foo\n"})
+		st := &storage.LayeredStorage{Personal: personal, Shared: []*storage.SharedStorage{team}}
+
+		// when
+		p, err := st.Find("foo")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, project.Project{UUID: "2", Name: "foo", Source: "team"}, p)
+	})
+
+	t.Run("returns error when project is not found", func(t *testing.T) {
+		// given
+		personal := new(test.MockStorage)
+		personal.On("List").Return([]project.Project(nil), nil).Once()
+
+		st := &storage.LayeredStorage{Personal: personal}
+
+		// when
+		_, err := st.Find("foo")
+
+		// then
+		assert.True(t, storage.ErrProjectNotFound.Equal(err))
+	})
+}
+
+func Test_LayeredStorage_Save(t *testing.T) {
+	t.Run("saves personal template", func(t *testing.T) {
+		// given
+		p := &project.Project{Name: "foo"}
+
+		personal := new(test.MockStorage)
+		personal.On("Save", p).Return(nil).Once()
+
+		st := &storage.LayeredStorage{Personal: personal}
+
+		// when
+		err := st.Save(p)
+
+		// then
+		assert.Nil(t, err)
+		personal.AssertExpectations(t)
+	})
+
+	t.Run("refuses to save shared template", func(t *testing.T) {
+		// given
+		personal := new(test.MockStorage)
+		st := &storage.LayeredStorage{Personal: personal}
+
+		// when
+		err := st.Save(&project.Project{Name: "foo", Source: "team"})
+
+		// then
+		assert.True(t, storage.ErrReadOnlySource.Equal(err))
+		personal.AssertNotCalled(t, "Save")
+	})
+}
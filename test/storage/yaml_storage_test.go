@@ -1,12 +1,15 @@
 package storage_test
 
 import (
+	iofs "io/fs"
 	"os"
 	"testing"
 	"thop/internal/config"
 	"thop/internal/storage"
+	"thop/internal/types/command"
 	"thop/internal/types/project"
 	"thop/internal/types/template"
+	"thop/internal/types/window"
 	"thop/test"
 
 	"github.com/stretchr/testify/assert"
@@ -262,3 +265,52 @@ func Test_PrepareTemplateFile(t *testing.T) {
 		fs.AssertExpectations(t)
 	})
 }
+
+func Test_FindSnippet(t *testing.T) {
+	cfg := &config.Config{
+		ConfigDir: "/foo/bar",
+	}
+
+	t.Run("reads snippet from snippets dir", func(t *testing.T) {
+		// given
+		fs := new(test.MockFileSystem)
+		fs.On("ReadFile", "/foo/bar/snippets/git.yaml").Return([]byte("name: git\nrun:\n  - lazygit\n"), nil).Once()
+
+		st := &storage.YamlStorage{Config: cfg, FileSystem: fs}
+
+		// when
+		w, err := st.FindSnippet("git")
+
+		// then
+		assert.Nil(t, err)
+		assert.Equal(t, window.Window{Name: "git", Commands: []command.Command{"lazygit"}}, w)
+		fs.AssertExpectations(t)
+	})
+
+	t.Run("returns error when snippet doesn't exist", func(t *testing.T) {
+		// given
+		fs := new(test.MockFileSystem)
+		fs.On("ReadFile", "/foo/bar/snippets/git.yaml").Return([]byte(nil), iofs.ErrNotExist).Once()
+
+		st := &storage.YamlStorage{Config: cfg, FileSystem: fs}
+
+		// when
+		_, err := st.FindSnippet("git")
+
+		// then
+		assert.True(t, storage.ErrSnippetNotFound.Equal(err))
+	})
+
+	t.Run("rejects names leaving snippets dir", func(t *testing.T) {
+		// given
+		fs := new(test.MockFileSystem)
+		st := &storage.YamlStorage{Config: cfg, FileSystem: fs}
+
+		// when
+		_, err := st.FindSnippet("../templates/foo")
+
+		// then
+		assert.True(t, storage.ErrSnippetNotFound.Equal(err))
+		fs.AssertNotCalled(t, "ReadFile", mock.Anything)
+	})
+}
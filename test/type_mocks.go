@@ -1,9 +1,17 @@
 package test
 
 import (
+	"io"
 	"os/exec"
+	"thop/internal/bundle"
+	"thop/internal/git"
+	"thop/internal/history"
+	"thop/internal/multiplexer"
+	"thop/internal/selector"
+	"thop/internal/service"
 	"thop/internal/types/project"
 	"thop/internal/types/template"
+	"thop/internal/types/window"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -31,16 +39,61 @@ func (m *MockMultiplexer) AttachProject(p project.Project) error {
 	return args.Error(0)
 }
 
+func (m *MockMultiplexer) StartProject(p project.Project) error {
+	args := m.Called(p)
+	return args.Error(0)
+}
+
+func (m *MockMultiplexer) RebuildProject(p project.Project) error {
+	args := m.Called(p)
+	return args.Error(0)
+}
+
+func (m *MockMultiplexer) UpdateProject(p project.Project) error {
+	args := m.Called(p)
+	return args.Error(0)
+}
+
+func (m *MockMultiplexer) Plan(p project.Project) ([][]string, error) {
+	args := m.Called(p)
+	return args.Get(0).([][]string), args.Error(1)
+}
+
 func (m *MockMultiplexer) ListActiveSessions() ([]project.Project, error) {
 	args := m.Called()
 	return args.Get(0).([]project.Project), args.Error(1)
 }
 
+func (m *MockMultiplexer) ListSessionsOn(socket multiplexer.Socket) ([]project.Project, error) {
+	args := m.Called(socket)
+	return args.Get(0).([]project.Project), args.Error(1)
+}
+
+func (m *MockMultiplexer) SessionRoot(sessionName multiplexer.SessionName) (string, error) {
+	args := m.Called(sessionName)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockMultiplexer) KillSession(p project.Project) error {
 	args := m.Called(p)
 	return args.Error(0)
 }
 
+func (m *MockMultiplexer) ResolveSessionName(p project.Project) (multiplexer.SessionName, error) {
+	args := m.Called(p)
+	return args.Get(0).(multiplexer.SessionName), args.Error(1)
+}
+
+func (m *MockMultiplexer) CurrentSession() (multiplexer.SessionName, error) {
+	args := m.Called()
+	return args.Get(0).(multiplexer.SessionName), args.Error(1)
+}
+
+func (m *MockMultiplexer) PreviousSession() (multiplexer.SessionName, error) {
+	args := m.Called()
+	return args.Get(0).(multiplexer.SessionName), args.Error(1)
+}
+
 type MockStorage struct {
 	mock.Mock
 }
@@ -70,6 +123,11 @@ func (m *MockStorage) PrepareTemplateFile(p project.Project) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockStorage) FindSnippet(name window.Snippet) (window.Window, error) {
+	args := m.Called(name)
+	return args.Get(0).(window.Window), args.Error(1)
+}
+
 type MockService struct {
 	mock.Mock
 }
@@ -79,8 +137,18 @@ func (m *MockService) CreateProject(root template.Root, name project.Name) error
 	return args.Error(0)
 }
 
-func (m *MockService) OpenProject(name project.Name) error {
-	args := m.Called(name)
+func (m *MockService) OpenProject(name project.Name, opts service.OpenOptions) error {
+	args := m.Called(name, opts)
+	return args.Error(0)
+}
+
+func (m *MockService) OpenProjects(names []project.Name) error {
+	args := m.Called(names)
+	return args.Error(0)
+}
+
+func (m *MockService) OpenLast() error {
+	args := m.Called()
 	return args.Error(0)
 }
 
@@ -89,13 +157,58 @@ func (m *MockService) DeleteProject(name project.Name) error {
 	return args.Error(0)
 }
 
+func (m *MockService) DeleteProjects(names []project.Name) error {
+	args := m.Called(names)
+	return args.Error(0)
+}
+
 func (m *MockService) EditProject(name project.Name) error {
 	args := m.Called(name)
 	return args.Error(0)
 }
 
-func (m *MockService) KillSession(name project.Name) error {
+func (m *MockService) KillSession(name project.Name, opts service.KillOptions) error {
+	args := m.Called(name, opts)
+	return args.Error(0)
+}
+
+func (m *MockService) KillSessions(names []project.Name, opts service.KillOptions) error {
+	args := m.Called(names, opts)
+	return args.Error(0)
+}
+
+func (m *MockService) ReloadProject(name project.Name, opts service.ReloadOptions) error {
+	args := m.Called(name, opts)
+	return args.Error(0)
+}
+
+func (m *MockService) ListProjects(filter service.ListFilter) ([]service.ListItem, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]service.ListItem), args.Error(1)
+}
+
+func (m *MockService) ShowProject(name project.Name) (service.ProjectPlan, error) {
+	args := m.Called(name)
+	return args.Get(0).(service.ProjectPlan), args.Error(1)
+}
+
+func (m *MockService) ExportScript(name project.Name) (service.ProjectPlan, error) {
 	args := m.Called(name)
+	return args.Get(0).(service.ProjectPlan), args.Error(1)
+}
+
+func (m *MockService) ImportProjects(path string, opts service.ImportOptions) error {
+	args := m.Called(path, opts)
+	return args.Error(0)
+}
+
+func (m *MockService) ExportProjects(names []project.Name, all bool) (bundle.Bundle, error) {
+	args := m.Called(names, all)
+	return args.Get(0).(bundle.Bundle), args.Error(1)
+}
+
+func (m *MockService) WriteSelectorEntries(w io.Writer) error {
+	args := m.Called(w)
 	return args.Error(0)
 }
 
@@ -107,3 +220,85 @@ func (s *MockProjectSelector) SelectFrom(items []project.Project, prompt string)
 	args := s.Called(items, prompt)
 	return args.Get(0).(*project.Project), args.Error(1)
 }
+
+func (s *MockProjectSelector) SelectMany(items []project.Project, prompt string) ([]project.Project, error) {
+	args := s.Called(items, prompt)
+	return args.Get(0).([]project.Project), args.Error(1)
+}
+
+// SelectAction calls load just like a real selector would, and matches the mock against loaded items
+func (s *MockProjectSelector) SelectAction(load func() ([]project.Project, error), prompt string) (selector.Selection, error) {
+	items, err := load()
+	if err != nil {
+		return selector.Selection{}, err
+	}
+
+	args := s.Called(items, prompt)
+	return args.Get(0).(selector.Selection), args.Error(1)
+}
+
+func (s *MockProjectSelector) WriteEntries(w io.Writer, items []project.Project) error {
+	args := s.Called(w, items)
+	return args.Error(0)
+}
+
+type MockHistory struct {
+	mock.Mock
+}
+
+func (m *MockHistory) Record(p project.Project) error {
+	args := m.Called(p)
+	return args.Error(0)
+}
+
+func (m *MockHistory) Entries() ([]history.Entry, error) {
+	args := m.Called()
+	return args.Get(0).([]history.Entry), args.Error(1)
+}
+
+type MockPrompter struct {
+	mock.Mock
+}
+
+func (m *MockPrompter) Confirm(question string) (bool, error) {
+	args := m.Called(question)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockDiscoverer struct {
+	mock.Mock
+}
+
+func (m *MockDiscoverer) Discover() ([]string, error) {
+	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
+type MockGitClient struct {
+	mock.Mock
+}
+
+func (m *MockGitClient) ListWorktrees(dir string) ([]git.Worktree, error) {
+	args := m.Called(dir)
+	return args.Get(0).([]git.Worktree), args.Error(1)
+}
+
+func (m *MockGitClient) BranchExists(repo string, branch string) (bool, error) {
+	args := m.Called(repo, branch)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockGitClient) AddWorktree(repo string, path string, branch string, create bool) error {
+	args := m.Called(repo, path, branch, create)
+	return args.Error(0)
+}
+
+func (m *MockGitClient) IsClean(path string) (bool, error) {
+	args := m.Called(path)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockGitClient) RemoveWorktree(repo string, path string) error {
+	args := m.Called(repo, path)
+	return args.Error(0)
+}